@@ -1,21 +1,27 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"syscall"
 	"unicode"
 
 	"github.com/spf13/cobra"
 	"github.com/vladkampov/markdocify/internal/config"
+	"github.com/vladkampov/markdocify/internal/report"
 	"github.com/vladkampov/markdocify/internal/scraper"
 )
 
 var version = "dev" // Will be overridden by ldflags during build
 
 var rootCmd = &cobra.Command{
-	Use:   "markdocify [URL]",
+	Use:   "markdocify [URL|DIR]",
 	Short: "Comprehensively scrape documentation sites into Markdown",
 	Long: `markdocify is a CLI tool that comprehensively scrapes documentation websites
 and converts them into a single, well-formatted Markdown file.
@@ -27,31 +33,78 @@ Usage:
   markdocify -c config.yml                    # Use configuration file
   markdocify https://example.com/docs         # Comprehensive scrape (depth 8)
   markdocify https://example.com/docs -o out.md  # Custom output file
-  markdocify https://example.com/docs -d 5    # Custom depth (lighter scrape)`,
+  markdocify https://example.com/docs -d 5    # Custom depth (lighter scrape)
+  markdocify ./_build/html                    # Convert a local directory of HTML files
+  markdocify -c config.yml --state-dir .state --resume  # Continue an interrupted crawl
+  markdocify -c config.yml --warc-file crawl.warc.gz     # Archive every response
+  markdocify -c config.yml --replay crawl.warc.gz        # Re-run offline from an archive
+  markdocify -c config.yml --write-partial               # Keep what was scraped if stopped early
+  markdocify discover -c config.yml --format json        # List the URLs a scrape would visit`,
 	Version: version,
+	Args:    cobra.ArbitraryArgs,
 	RunE:    runScraper,
 }
 
+var discoverCmd = &cobra.Command{
+	Use:   "discover [URL|DIR]",
+	Short: "List the URLs a scrape would visit without converting them",
+	Long: `discover crawls links using the configured patterns, domains and depth, but
+skips content extraction and conversion. It prints the URL tree with each URL's
+depth and the rule that accepted or rejected it. Same as markdocify --dry-run.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun = true
+		return runScraper(cmd, args)
+	},
+}
+
 var configFile string
 var outputFile string
 var maxDepth int
 var concurrency int
+var stateDir string
+var resume bool
+var cacheDir string
+var reportFile string
+var warcFile string
+var replayFile string
+var writePartial bool
+var dryRun bool
+var discoverFormat string
 
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "", "Configuration file path")
 	rootCmd.PersistentFlags().StringVarP(&outputFile, "output", "o", "", "Output file path")
 	rootCmd.PersistentFlags().IntVarP(&maxDepth, "depth", "d", 8, "Maximum crawl depth (for URL mode)")
-	rootCmd.PersistentFlags().IntVar(&concurrency, "concurrency", 3, "Number of concurrent workers (for URL mode)")
+	rootCmd.PersistentFlags().IntVar(&concurrency, "concurrency", 3, "Concurrent requests per host (for URL mode)")
+	rootCmd.PersistentFlags().StringVar(&stateDir, "state-dir", "", "Directory for crawl checkpoints")
+	rootCmd.PersistentFlags().BoolVar(&resume, "resume", false, "Resume an interrupted crawl from --state-dir")
+	rootCmd.PersistentFlags().StringVar(&cacheDir, "cache-dir", "", "Directory for the page cache used by incremental re-scrapes")
+	rootCmd.PersistentFlags().StringVar(&reportFile, "report-file", "", "Write a JSON report of every URL seen to this file")
+	rootCmd.PersistentFlags().StringVar(&warcFile, "warc-file", "", "Record every HTTP request and response to this WARC file (.gz to compress)")
+	rootCmd.PersistentFlags().StringVar(&replayFile, "replay", "", "Replay responses from this WARC file instead of fetching them")
+	rootCmd.PersistentFlags().BoolVar(&writePartial, "write-partial", false, "Write the pages scraped so far when interrupted or timed out")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "List the URLs a scrape would visit without converting them")
+	rootCmd.PersistentFlags().StringVar(&discoverFormat, "format", "text", "Dry-run output format: text or json")
+
+	rootCmd.AddCommand(discoverCmd)
 }
 
 func runScraper(cmd *cobra.Command, args []string) error {
 	var cfg *config.Config
 	var err error
 
+	if dryRun && discoverFormat != "text" && discoverFormat != "json" {
+		return fmt.Errorf("invalid --format %q: expected text or json", discoverFormat)
+	}
+
 	// Check if URL is provided as argument (quick mode)
 	if len(args) > 0 && configFile == "" {
-		url := args[0]
-		cfg, err = createQuickConfig(url)
+		if info, statErr := os.Stat(args[0]); statErr == nil && info.IsDir() {
+			cfg, err = createFilesystemConfig(args[0])
+		} else {
+			cfg, err = createQuickConfig(args[0])
+		}
 		if err != nil {
 			return fmt.Errorf("failed to create quick config: %w", err)
 		}
@@ -69,12 +122,50 @@ func runScraper(cmd *cobra.Command, args []string) error {
 		cfg.OutputFile = outputFile
 	}
 
+	if stateDir != "" {
+		cfg.State.Dir = stateDir
+	}
+	if resume {
+		cfg.State.Resume = true
+	}
+	if cfg.State.Resume && cfg.State.Dir == "" {
+		return fmt.Errorf("--resume requires --state-dir (or state.dir in the configuration file)")
+	}
+	if cacheDir != "" {
+		cfg.Cache.Dir = cacheDir
+	}
+	if reportFile != "" {
+		cfg.ReportFile = reportFile
+	}
+	if warcFile != "" {
+		cfg.WARCFile = warcFile
+	}
+	if writePartial {
+		cfg.Output.WritePartial = true
+	}
+	if replayFile != "" {
+		cfg.Source = config.SourceConfig{Type: config.SourceTypeWARC, Path: replayFile}
+	}
+	if warcFile != "" || replayFile != "" {
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("invalid configuration: %w", err)
+		}
+	}
+
 	scraperInstance, err := scraper.New(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to create scraper: %w", err)
 	}
 
-	if err := scraperInstance.Run(); err != nil {
+	// Ctrl-C or SIGTERM aborts the requests in flight and stops the crawl
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if dryRun {
+		return runDiscovery(ctx, cmd, scraperInstance)
+	}
+
+	if err := scraperInstance.RunWithContext(ctx); err != nil {
 		return fmt.Errorf("scraping failed: %w", err)
 	}
 
@@ -82,6 +173,35 @@ func runScraper(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// runDiscovery crawls without converting and prints the URL tree in the
+// --format chosen. The tree is printed even if the crawl fails part way.
+func runDiscovery(ctx context.Context, cmd *cobra.Command, s *scraper.Scraper) error {
+	doc, runErr := s.Discover(ctx)
+	out := cmd.OutOrStdout()
+
+	var err error
+	if discoverFormat == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(struct {
+			Name     string                 `json:"name"`
+			Complete bool                   `json:"complete"`
+			Summary  map[report.Outcome]int `json:"summary"`
+			URLs     []*report.Node         `json:"urls"`
+		}{doc.Name, doc.Complete, doc.Summary, doc.Tree()})
+	} else {
+		err = report.WriteTree(out, doc.Tree())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write URL tree: %w", err)
+	}
+
+	if runErr != nil {
+		return fmt.Errorf("discovery failed: %w", runErr)
+	}
+	return nil
+}
+
 func createQuickConfig(inputURL string) (*config.Config, error) {
 	// Validate URL
 	parsedURL, err := url.Parse(inputURL)
@@ -137,85 +257,9 @@ func createQuickConfig(inputURL string) (*config.Config, error) {
 		StartURLs:  []string{inputURL},
 		
 		FollowPatterns: followPatterns,
-		IgnorePatterns: []string{
-			// Media files
-			".*\\.(jpg|jpeg|png|gif|svg|css|js|ico|woff|woff2|ttf|eot|pdf|zip|tar|gz)$",
-			// Non-documentation pages
-			".*/edit.*$",
-			".*/settings.*$",
-			".*/login.*$",
-			".*/logout.*$",
-			".*/signin.*$",
-			".*/signup.*$",
-			".*/register.*$",
-			".*/contact.*$",
-			".*/support.*$",
-			".*/pricing.*$",
-			".*/about.*$",
-			".*/careers.*$",
-			".*/jobs.*$",
-			".*/legal.*$",
-			".*/terms.*$",
-			".*/privacy.*$",
-			// Social and external
-			".*github\\.com.*$",
-			".*twitter\\.com.*$",
-			".*linkedin\\.com.*$",
-			".*facebook\\.com.*$",
-			".*youtube\\.com.*$",
-			".*discord\\.(gg|com).*$",
-			".*slack\\.com.*$",
-			// Admin and user-specific
-			".*/admin.*$",
-			".*/dashboard.*$",
-			".*/account.*$",
-			".*/profile.*$",
-			".*/user/.*$",
-			// Interactive features that aren't documentation
-			".*/playground.*$",
-			".*/editor.*$",
-			".*/sandbox.*$",
-		},
+		IgnorePatterns: quickIgnorePatterns(),
 
-		Selectors: config.SelectorConfig{
-			Title:   "h1, title, .page-title, .doc-title, [data-testid='page-title']",
-			Content: strings.Join([]string{
-				// Primary content containers
-				"main", "article", ".content", ".documentation", ".docs", "#content", ".main-content",
-				// Documentation-specific containers
-				".doc-content", ".docs-content", ".documentation-content", ".guide-content",
-				".tutorial-content", ".reference-content", ".api-content", ".markdown-body",
-				// Framework-specific patterns
-				".docusaurus_skipToContent_node", ".nextra-content", ".vuepress-content",
-				".gitbook-content", ".notion-page-content", ".sphinx-content",
-				// Generic content patterns
-				"[role='main']", "[data-content]", ".page-content", ".post-content",
-				".entry-content", ".single-content", "#main-content", "#primary-content",
-				// Fallback to body if nothing else matches
-				"body",
-			}, ", "),
-			Exclude: []string{
-				// Navigation elements
-				"nav", "header", "footer", ".navigation", ".sidebar", ".toc", ".table-of-contents",
-				".menu", ".nav", ".navbar", ".topbar", ".breadcrumb", ".breadcrumbs",
-				// Interactive elements
-				".edit-link", ".edit-page", ".edit-this-page", ".feedback", ".prev-next",
-				".pagination", ".page-nav", ".site-nav", ".social", ".share",
-				// Code/technical elements to exclude from main content
-				"script", "style", "noscript", ".highlight", ".code-toolbar",
-				// Ads and tracking
-				".advertisement", ".ads", ".ad", ".promo", ".banner", ".cookie",
-				// Comments and social
-				".comments", ".disqus", ".utterances", ".giscus", ".social-share",
-				// Search and forms
-				".search", ".search-box", ".search-form", ".newsletter", ".subscribe",
-				// Specific known patterns from popular doc sites
-				".cmdklaunch_wrapper__KrfZL", ".mobile-menu_root__PX9iM", ".toggle_mobileMenuToggle__W5y02",
-				".header_header__TSZx7", "[data-testid='header']", ".DocSearch", ".algolia-docsearch",
-				// Version/language switchers
-				".version-switcher", ".lang-switcher", ".theme-switcher", ".locale-switcher",
-			},
-		},
+		Selectors: quickSelectors(),
 
 		Processing: config.ProcessingConfig{
 			MaxDepth:           maxDepth,
@@ -235,7 +279,6 @@ func createQuickConfig(inputURL string) (*config.Config, error) {
 		},
 
 		Output: config.OutputConfig{
-			HeadingOffset:      0,
 			IncludeMetadata:    true,
 			SyntaxHighlighting: true,
 			PreserveImages:     false,
@@ -267,12 +310,159 @@ func createQuickConfig(inputURL string) (*config.Config, error) {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
-	fmt.Printf("Quick scrape mode: %s -> %s\n", inputURL, outputFile)
-	fmt.Printf("Max depth: %d, Concurrency: %d\n", maxDepth, concurrency)
+	// Keep stdout to the URL tree in dry-run mode
+	if !dryRun {
+		fmt.Printf("Quick scrape mode: %s -> %s\n", inputURL, outputFile)
+		fmt.Printf("Max depth: %d, Concurrency: %d\n", maxDepth, concurrency)
+	}
 
 	return cfg, nil
 }
 
+// createFilesystemConfig builds a quick-mode configuration that converts
+// the HTML files in dir without touching the network.
+func createFilesystemConfig(dir string) (*config.Config, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid directory: %w", err)
+	}
+
+	name := filepath.Base(root)
+	if outputFile == "" {
+		outputFile = fmt.Sprintf("%s-docs.md", name)
+	}
+
+	cfg := &config.Config{
+		Name:       fmt.Sprintf("%s Documentation", titleCase(strings.NewReplacer("-", " ", "_", " ").Replace(name))),
+		OutputFile: outputFile,
+		Source: config.SourceConfig{
+			Type: config.SourceTypeFilesystem,
+			Root: root,
+		},
+
+		IgnorePatterns: quickIgnorePatterns(),
+		Selectors:      quickSelectors(),
+
+		Processing: config.ProcessingConfig{
+			MaxDepth:           maxDepth,
+			Concurrency:        concurrency,
+			PreserveCodeBlocks: true,
+			GenerateTOC:        true,
+			SanitizeHTML:       true,
+		},
+
+		Output: config.OutputConfig{
+			IncludeMetadata:    true,
+			SyntaxHighlighting: true,
+		},
+
+		Monitoring: config.MonitoringConfig{
+			LogLevel:        "info",
+			ProgressUpdates: true,
+		},
+	}
+
+	if err := cfg.SetDefaults(); err != nil {
+		return nil, fmt.Errorf("failed to set defaults: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if !dryRun {
+		fmt.Printf("Offline mode: %s -> %s\n", root, outputFile)
+	}
+	return cfg, nil
+}
+
+// quickIgnorePatterns skips assets and common non-documentation pages in
+// quick mode.
+func quickIgnorePatterns() []string {
+	return []string{
+		// Media files
+		".*\\.(jpg|jpeg|png|gif|svg|css|js|ico|woff|woff2|ttf|eot|pdf|zip|tar|gz)$",
+		// Non-documentation pages
+		".*/edit.*$",
+		".*/settings.*$",
+		".*/login.*$",
+		".*/logout.*$",
+		".*/signin.*$",
+		".*/signup.*$",
+		".*/register.*$",
+		".*/contact.*$",
+		".*/support.*$",
+		".*/pricing.*$",
+		".*/about.*$",
+		".*/careers.*$",
+		".*/jobs.*$",
+		".*/legal.*$",
+		".*/terms.*$",
+		".*/privacy.*$",
+		// Social and external
+		".*github\\.com.*$",
+		".*twitter\\.com.*$",
+		".*linkedin\\.com.*$",
+		".*facebook\\.com.*$",
+		".*youtube\\.com.*$",
+		".*discord\\.(gg|com).*$",
+		".*slack\\.com.*$",
+		// Admin and user-specific
+		".*/admin.*$",
+		".*/dashboard.*$",
+		".*/account.*$",
+		".*/profile.*$",
+		".*/user/.*$",
+		// Interactive features that aren't documentation
+		".*/playground.*$",
+		".*/editor.*$",
+		".*/sandbox.*$",
+	}
+}
+
+// quickSelectors covers the content containers of popular documentation
+// frameworks in quick mode.
+func quickSelectors() config.SelectorConfig {
+	return config.SelectorConfig{
+		Title:   "h1, title, .page-title, .doc-title, [data-testid='page-title']",
+		Content: strings.Join([]string{
+			// Primary content containers
+			"main", "article", ".content", ".documentation", ".docs", "#content", ".main-content",
+			// Documentation-specific containers
+			".doc-content", ".docs-content", ".documentation-content", ".guide-content",
+			".tutorial-content", ".reference-content", ".api-content", ".markdown-body",
+			// Framework-specific patterns
+			".docusaurus_skipToContent_node", ".nextra-content", ".vuepress-content",
+			".gitbook-content", ".notion-page-content", ".sphinx-content",
+			// Generic content patterns
+			"[role='main']", "[data-content]", ".page-content", ".post-content",
+			".entry-content", ".single-content", "#main-content", "#primary-content",
+			// Fallback to body if nothing else matches
+			"body",
+		}, ", "),
+		Exclude: []string{
+			// Navigation elements
+			"nav", "header", "footer", ".navigation", ".sidebar", ".toc", ".table-of-contents",
+			".menu", ".nav", ".navbar", ".topbar", ".breadcrumb", ".breadcrumbs",
+			// Interactive elements
+			".edit-link", ".edit-page", ".edit-this-page", ".feedback", ".prev-next",
+			".pagination", ".page-nav", ".site-nav", ".social", ".share",
+			// Code/technical elements to exclude from main content
+			"script", "style", "noscript", ".highlight", ".code-toolbar",
+			// Ads and tracking
+			".advertisement", ".ads", ".ad", ".promo", ".banner", ".cookie",
+			// Comments and social
+			".comments", ".disqus", ".utterances", ".giscus", ".social-share",
+			// Search and forms
+			".search", ".search-box", ".search-form", ".newsletter", ".subscribe",
+			// Specific known patterns from popular doc sites
+			".cmdklaunch_wrapper__KrfZL", ".mobile-menu_root__PX9iM", ".toggle_mobileMenuToggle__W5y02",
+			".header_header__TSZx7", "[data-testid='header']", ".DocSearch", ".algolia-docsearch",
+			// Version/language switchers
+			".version-switcher", ".lang-switcher", ".theme-switcher", ".locale-switcher",
+		},
+	}
+}
+
 func titleCase(s string) string {
 	words := strings.Fields(s)
 	for i, word := range words {
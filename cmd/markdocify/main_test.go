@@ -2,12 +2,20 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"regexp"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/vladkampov/markdocify/internal/config"
+	"github.com/vladkampov/markdocify/internal/report"
+	"github.com/vladkampov/markdocify/internal/scraper"
 )
 
 func TestVersion(t *testing.T) {
@@ -186,6 +194,8 @@ func TestCreateQuickConfig(t *testing.T) {
 	assert.True(t, cfg.Processing.PreserveCodeBlocks)
 	assert.True(t, cfg.Processing.GenerateTOC)
 	assert.True(t, cfg.Processing.SanitizeHTML)
+	require.NotNil(t, cfg.Output.HeadingOffset)
+	assert.Equal(t, config.DefaultHeadingOffset, *cfg.Output.HeadingOffset)
 	
 	// Test invalid URL
 	_, err = createQuickConfig("not-a-valid-url")
@@ -209,6 +219,76 @@ func TestCreateQuickConfig(t *testing.T) {
 	assert.Equal(t, "custom-output.md", cfg.OutputFile)
 }
 
+func TestQuickModeNestsHeadings(t *testing.T) {
+	origOutputFile := outputFile
+	origMaxDepth := maxDepth
+	defer func() {
+		outputFile = origOutputFile
+		maxDepth = origMaxDepth
+	}()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/docs" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`<html><body><h1>Guide</h1><p>Welcome</p>` +
+			`<h2>Install</h2><p>Steps</p></body></html>`))
+	}))
+	defer server.Close()
+
+	outputFile = filepath.Join(t.TempDir(), "quick.md")
+	maxDepth = 1
+	cfg, err := createQuickConfig("https://docs.example.com/docs")
+	require.NoError(t, err)
+	// Pointed at the test server, whose address is not a valid domain
+	cfg.StartURLs = []string{server.URL + "/docs"}
+	cfg.Security.AllowedDomains = nil
+	cfg.Processing.Delay = 0.01
+
+	s, err := scraper.New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, s.Run())
+
+	content, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	output := string(content)
+
+	title := regexp.MustCompile(`(?m)^(#+) Guide$`).FindStringSubmatch(output)
+	install := regexp.MustCompile(`(?m)^(#+) Install$`).FindStringSubmatch(output)
+	require.NotNil(t, title, output)
+	require.NotNil(t, install, output)
+	assert.Len(t, install[1], len(title[1])+1, "page headings nest under the page title:\n%s", output)
+}
+
+func TestRunScraperWithDirectory(t *testing.T) {
+	origOutputFile := outputFile
+	defer func() { outputFile = origOutputFile }()
+
+	site := filepath.Join(t.TempDir(), "my-site")
+	require.NoError(t, os.MkdirAll(filepath.Join(site, "guide"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(site, "index.html"),
+		[]byte(`<html><body><main><h1>Home</h1><p>Welcome</p><a href="guide/">Guide</a></main></body></html>`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(site, "guide", "index.html"),
+		[]byte(`<html><body><main><h1>Guide</h1><p>Read me</p></main></body></html>`), 0644))
+
+	outputFile = ""
+	cfg, err := createFilesystemConfig(site)
+	require.NoError(t, err)
+	assert.Equal(t, "My Site Documentation", cfg.Name)
+	assert.Equal(t, "my-site-docs.md", cfg.OutputFile)
+	assert.Equal(t, config.SourceTypeFilesystem, cfg.Source.Type)
+	assert.Equal(t, []string{config.DefaultFilesystemBaseURL}, cfg.StartURLs)
+
+	outputFile = filepath.Join(t.TempDir(), "offline.md")
+	require.NoError(t, runScraper(rootCmd, []string{site}))
+
+	content, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "Welcome")
+	assert.Contains(t, string(content), "Read me")
+}
+
 func TestMain(t *testing.T) {
 	tests := []struct {
 		name string
@@ -274,4 +354,41 @@ func captureOutput(t *testing.T, fn func()) string {
 	require.NoError(t, err)
 	
 	return buf.String()
-}
\ No newline at end of file
+}
+func TestDiscoverCommand(t *testing.T) {
+	origOutputFile, origFormat := outputFile, discoverFormat
+	defer func() {
+		outputFile, discoverFormat, dryRun = origOutputFile, origFormat, false
+	}()
+
+	site := filepath.Join(t.TempDir(), "site")
+	require.NoError(t, os.MkdirAll(filepath.Join(site, "guide"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(site, "index.html"),
+		[]byte(`<html><body><main><h1>Home</h1><a href="guide/">Guide</a><a href="login">Login</a></main></body></html>`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(site, "guide", "index.html"),
+		[]byte(`<html><body><main><h1>Guide</h1></main></body></html>`), 0644))
+
+	outputFile = filepath.Join(t.TempDir(), "never.md")
+	discoverFormat = "json"
+	output := captureOutput(t, func() {
+		rootCmd.SetArgs([]string{"discover", site})
+		require.NoError(t, rootCmd.Execute())
+	})
+	assert.NoFileExists(t, outputFile)
+
+	var tree struct {
+		Complete bool           `json:"complete"`
+		URLs     []*report.Node `json:"urls"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(output), &tree))
+	assert.True(t, tree.Complete)
+	require.NotEmpty(t, tree.URLs)
+	assert.Equal(t, "file:///", tree.URLs[0].URL)
+	assert.Equal(t, report.OutcomeDiscovered, tree.URLs[0].Outcome)
+	require.Len(t, tree.URLs[0].Children, 2)
+	assert.Equal(t, report.OutcomeDiscovered, tree.URLs[0].Children[0].Outcome)
+	assert.Equal(t, report.OutcomeSkipCategory, tree.URLs[0].Children[1].Outcome)
+
+	discoverFormat = "xml"
+	assert.Error(t, runScraper(rootCmd, []string{site}))
+}
@@ -19,7 +19,30 @@ type Aggregator struct {
 	config        *config.Config
 	pages         []*Page
 	mu            sync.RWMutex
-	contentHashes map[string]bool
+	// contentHashes maps a content hash to the URL of the page holding it
+	contentHashes map[string]string
+	// aliases maps a canonical page URL to the other URLs that led to it
+	aliases map[string]map[string]bool
+
+	// totalSize is the size of all aggregated page content in bytes
+	totalSize      int64
+	budgetExceeded bool
+
+	// navigation is the site's navigation tree, used to order pages
+	navigation []*NavItem
+
+	// generatedAt overrides the generation date written to the output
+	generatedAt time.Time
+
+	// incomplete is why the crawl stopped early, with the URLs it left
+	incomplete string
+	unvisited  []string
+
+	// canonicalize normalizes link targets; internalLinks and
+	// externalLinks count the links of the last output by where they lead
+	canonicalize  func(string) string
+	internalLinks int
+	externalLinks int
 }
 
 type Page struct {
@@ -28,24 +51,120 @@ type Page struct {
 	Content   string
 	Depth     int
 	Timestamp time.Time
+	Aliases   []string `json:",omitempty"`
 }
 
+// AddResult reports what AddPage did with a page.
+type AddResult int
+
+const (
+	PageAdded AddResult = iota
+	PageDuplicate
+	PageOverBudget
+)
+
 func New(cfg *config.Config) (*Aggregator, error) {
 	return &Aggregator{
 		config:        cfg,
 		pages:         make([]*Page, 0),
-		contentHashes: make(map[string]bool),
+		contentHashes: make(map[string]string),
+		aliases:       make(map[string]map[string]bool),
 	}, nil
 }
 
-func (a *Aggregator) AddPage(url, title, content string, depth int) {
+func (a *Aggregator) AddPage(url, title, content string, depth int) AddResult {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
+	return a.addPage(&Page{
+		URL:       url,
+		Title:     title,
+		Content:   content,
+		Depth:     depth,
+		Timestamp: time.Now(),
+	})
+}
+
+// RestorePages re-adds pages aggregated by an earlier run, such as those
+// loaded from a checkpoint. Duplicate content is skipped as in AddPage.
+func (a *Aggregator) RestorePages(pages []*Page) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, page := range pages {
+		restored := *page
+		a.addPage(&restored)
+	}
+}
+
+// Pages returns a snapshot of the aggregated pages.
+func (a *Aggregator) Pages() []*Page {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	pages := make([]*Page, len(a.pages))
+	for i, page := range a.pages {
+		snapshot := *page
+		snapshot.Aliases = a.aliasesOf(page.URL)
+		pages[i] = &snapshot
+	}
+	return pages
+}
+
+// AddAlias records that alias is another URL for the page at canonical.
+// Aliases may be added before or after the page itself.
+func (a *Aggregator) AddAlias(canonical, alias string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.addAlias(canonical, alias)
+}
+
+func (a *Aggregator) addAlias(canonical, alias string) {
+	if alias == canonical {
+		return
+	}
+	if a.aliases[canonical] == nil {
+		a.aliases[canonical] = make(map[string]bool)
+	}
+	a.aliases[canonical][alias] = true
+}
+
+// Aliases returns the sorted alias URLs recorded for canonical.
+func (a *Aggregator) Aliases(canonical string) []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.aliasesOf(canonical)
+}
+
+func (a *Aggregator) aliasesOf(canonical string) []string {
+	if len(a.aliases[canonical]) == 0 {
+		return nil
+	}
+	aliases := make([]string, 0, len(a.aliases[canonical]))
+	for alias := range a.aliases[canonical] {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	return aliases
+}
+
+func (a *Aggregator) addPage(page *Page) AddResult {
+	for _, alias := range page.Aliases {
+		a.addAlias(page.URL, alias)
+	}
+	page.Aliases = nil
+
 	// Check for duplicate content using hash
-	contentHash := fmt.Sprintf("%x", sha256.Sum256([]byte(content)))
-	if a.contentHashes[contentHash] {
-		return // Skip duplicate content
+	contentHash := fmt.Sprintf("%x", sha256.Sum256([]byte(page.Content)))
+	if original, ok := a.contentHashes[contentHash]; ok {
+		// Skip duplicate content, but remember where else it was found
+		a.addAlias(original, page.URL)
+		return PageDuplicate
+	}
+
+	if !a.fitsBudget(page) {
+		a.budgetExceeded = true
+		return PageOverBudget
 	}
 
 	// Memory management warning
@@ -54,16 +173,40 @@ func (a *Aggregator) AddPage(url, title, content string, depth int) {
 		fmt.Printf("Warning: Approaching memory limit with %d pages\n", len(a.pages))
 	}
 
-	page := &Page{
-		URL:       url,
-		Title:     title,
-		Content:   content,
-		Depth:     depth,
-		Timestamp: time.Now(),
-	}
-	
 	a.pages = append(a.pages, page)
-	a.contentHashes[contentHash] = true
+	a.contentHashes[contentHash] = page.URL
+	a.totalSize += int64(len(page.Content))
+	return PageAdded
+}
+
+// fitsBudget reports whether page can be added without going over
+// max_pages or max_output_size.
+func (a *Aggregator) fitsBudget(page *Page) bool {
+	if a.budgetExceeded {
+		return false
+	}
+	if maxPages := a.config.Processing.MaxPages; maxPages > 0 && len(a.pages) >= maxPages {
+		return false
+	}
+	if maxSize := a.config.Output.MaxOutputSizeBytes; maxSize > 0 && a.totalSize+int64(len(page.Content)) > maxSize {
+		return false
+	}
+	return true
+}
+
+// BudgetExceeded reports whether a page was rejected because the output
+// budget is used up. Once set, no further pages are accepted.
+func (a *Aggregator) BudgetExceeded() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.budgetExceeded
+}
+
+// TotalSize returns the size of the aggregated page content in bytes.
+func (a *Aggregator) TotalSize() int64 {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.totalSize
 }
 
 func (a *Aggregator) GetPageCount() int {
@@ -79,17 +222,34 @@ func (a *Aggregator) GenerateOutput() error {
 
 	a.sortPages()
 
+	// Headings written before the pages take their anchors first
+	var preceding []string
+	if a.config.Output.IncludeMetadata {
+		preceding = append(preceding, a.config.Name)
+	}
+	if a.config.Processing.GenerateTOC {
+		preceding = append(preceding, "Table of Contents")
+	}
+	sections, index := a.sections(preceding...)
+
 	var output strings.Builder
 
 	if a.config.Output.IncludeMetadata {
 		a.writeMetadata(&output)
 	}
+	if a.incomplete != "" {
+		output.WriteString(fmt.Sprintf("> **Incomplete:** %s. %d queued URLs were not visited.\n\n", a.incomplete, len(a.unvisited)))
+	}
 
 	if a.config.Processing.GenerateTOC {
-		a.writeTableOfContents(&output)
+		a.writeTableOfContents(&output, sections)
 	}
 
-	a.writeContent(&output)
+	a.writeContent(&output, sections, index)
+
+	if a.incomplete != "" && len(a.unvisited) > 0 {
+		a.writeUnvisited(&output)
+	}
 
 	return a.writeToFile(output.String())
 }
@@ -103,22 +263,54 @@ func (a *Aggregator) sortPages() {
 	})
 }
 
+// SetGeneratedAt fixes the generation date written to the output, so that
+// replaying an archived crawl reproduces the original output exactly.
+func (a *Aggregator) SetGeneratedAt(t time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.generatedAt = t
+}
+
+// SetIncomplete marks the output as cut short by reason, listing the
+// URLs that were queued but never visited.
+func (a *Aggregator) SetIncomplete(reason string, unvisited []string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.incomplete = reason
+	a.unvisited = unvisited
+}
+
+func (a *Aggregator) writeUnvisited(output *strings.Builder) {
+	output.WriteString("## Unvisited URLs\n\n")
+	for _, u := range a.unvisited {
+		output.WriteString(fmt.Sprintf("- %s\n", u))
+	}
+	output.WriteString("\n")
+}
+
 func (a *Aggregator) writeMetadata(output *strings.Builder) {
+	generatedAt := a.generatedAt
+	if generatedAt.IsZero() {
+		generatedAt = time.Now()
+	}
 	output.WriteString("# " + a.config.Name + "\n\n")
-	output.WriteString(fmt.Sprintf("*Generated on %s*\n\n", time.Now().Format("2006-01-02 15:04:05")))
+	output.WriteString(fmt.Sprintf("*Generated on %s*\n\n", generatedAt.Format("2006-01-02 15:04:05")))
 	output.WriteString(fmt.Sprintf("- **Base URL**: %s\n", a.config.BaseURL))
 	output.WriteString(fmt.Sprintf("- **Total Pages**: %d\n", len(a.pages)))
-	output.WriteString(fmt.Sprintf("- **Max Depth**: %d\n\n", a.config.Processing.MaxDepth))
+	output.WriteString(fmt.Sprintf("- **Max Depth**: %d\n", a.config.Processing.MaxDepth))
+	if a.budgetExceeded {
+		output.WriteString("- **Note**: Output budget reached, the crawl was stopped early\n")
+	}
+	output.WriteString("\n")
 	output.WriteString("---\n\n")
 }
 
-func (a *Aggregator) writeTableOfContents(output *strings.Builder) {
+func (a *Aggregator) writeTableOfContents(output *strings.Builder, sections []section) {
 	output.WriteString("## Table of Contents\n\n")
 	
-	for _, page := range a.pages {
-		indent := strings.Repeat("  ", page.Depth)
-		anchor := a.createAnchor(page.Title)
-		output.WriteString(fmt.Sprintf("%s- [%s](#%s)\n", indent, page.Title, anchor))
+	for _, s := range sections {
+		indent := strings.Repeat("  ", s.level)
+		output.WriteString(fmt.Sprintf("%s- [%s](#%s)\n", indent, s.title, s.anchor))
 	}
 	
 	output.WriteString("\n---\n\n")
@@ -165,30 +357,37 @@ func (a *Aggregator) createAnchor(title string) string {
 	return anchor
 }
 
-func (a *Aggregator) writeContent(output *strings.Builder) {
-	for i, page := range a.pages {
-		if i > 0 {
-			output.WriteString("\n\n---\n\n")
-		}
+func (a *Aggregator) writeContent(output *strings.Builder, sections []section, index *anchorIndex) {
+	a.internalLinks, a.externalLinks = 0, 0
 
-		pageTitle := page.Title
-		if pageTitle == "" || pageTitle == "Untitled" {
-			pageTitle = a.extractTitleFromURL(page.URL)
+	afterPage := false
+	for _, s := range sections {
+		// Sections are kept together with their first page
+		if afterPage {
+			output.WriteString("\n\n---\n\n")
 		}
+		afterPage = s.page != nil
 
-		headingLevel := page.Depth + 1
+		page := s.page
+		headingLevel := s.level + 1
 		if headingLevel > 6 {
 			headingLevel = 6
 		}
 		
 		headingPrefix := strings.Repeat("#", headingLevel)
-		output.WriteString(fmt.Sprintf("%s %s\n\n", headingPrefix, pageTitle))
+		output.WriteString(fmt.Sprintf("%s %s\n\n", headingPrefix, s.title))
+		if page == nil {
+			continue
+		}
 
 		if a.config.Output.IncludeMetadata {
 			output.WriteString(fmt.Sprintf("*Source: [%s](%s)*\n\n", page.URL, page.URL))
+			if aliases := a.aliasesOf(page.URL); len(aliases) > 0 {
+				output.WriteString(fmt.Sprintf("*Also available at: %s*\n\n", strings.Join(aliases, ", ")))
+			}
 		}
 
-		content := strings.TrimSpace(page.Content)
+		content := a.internalizeLinks(s.content, index)
 		if content != "" {
 			output.WriteString(content)
 			output.WriteString("\n")
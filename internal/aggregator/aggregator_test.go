@@ -3,6 +3,8 @@ package aggregator
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -200,3 +202,294 @@ func TestMemoryLimitWarning(t *testing.T) {
 	assert.Equal(t, 5, agg.GetPageCount())
 }
 
+
+func TestOutputBudget(t *testing.T) {
+	t.Run("max pages", func(t *testing.T) {
+		cfg := &config.Config{
+			Processing: config.ProcessingConfig{MaxPages: 2},
+		}
+		agg, err := New(cfg)
+		require.NoError(t, err)
+
+		agg.AddPage("https://example.com/a", "A", "Content A", 0)
+		agg.AddPage("https://example.com/b", "B", "Content B", 0)
+		assert.False(t, agg.BudgetExceeded())
+
+		agg.AddPage("https://example.com/c", "C", "Content C", 0)
+		assert.Equal(t, 2, agg.GetPageCount())
+		assert.True(t, agg.BudgetExceeded())
+	})
+
+	t.Run("max output size", func(t *testing.T) {
+		cfg := &config.Config{
+			Output: config.OutputConfig{MaxOutputSizeBytes: 20},
+		}
+		agg, err := New(cfg)
+		require.NoError(t, err)
+
+		agg.AddPage("https://example.com/a", "A", "0123456789", 0)
+		agg.AddPage("https://example.com/b", "B", "abcdefghijklmnop", 0)
+		assert.Equal(t, 1, agg.GetPageCount())
+		assert.True(t, agg.BudgetExceeded())
+		assert.Equal(t, int64(10), agg.TotalSize())
+
+		// Smaller pages are rejected too once the budget is reached
+		agg.AddPage("https://example.com/c", "C", "tiny", 0)
+		assert.Equal(t, 1, agg.GetPageCount())
+	})
+
+	t.Run("metadata notes early stop", func(t *testing.T) {
+		tempFile := t.TempDir() + "/budget.md"
+
+		cfg := &config.Config{
+			OutputFile: tempFile,
+			Output:     config.OutputConfig{IncludeMetadata: true},
+			Processing: config.ProcessingConfig{MaxPages: 1},
+		}
+		agg, err := New(cfg)
+		require.NoError(t, err)
+
+		agg.AddPage("https://example.com/a", "A", "Content A", 0)
+		agg.AddPage("https://example.com/b", "B", "Content B", 0)
+		require.NoError(t, agg.GenerateOutput())
+
+		data, err := os.ReadFile(tempFile)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "Output budget reached")
+	})
+}
+
+func TestAliases(t *testing.T) {
+	agg, err := New(&config.Config{})
+	require.NoError(t, err)
+
+	agg.AddAlias("https://example.com/a", "https://example.com/a/")
+	agg.AddAlias("https://example.com/a", "https://example.com/a#top")
+	agg.AddAlias("https://example.com/a", "https://example.com/a")
+	agg.AddPage("https://example.com/a", "A", "Content A", 0)
+
+	// Identical content under another URL becomes an alias
+	agg.AddPage("https://example.com/copy-of-a", "A", "Content A", 0)
+
+	expected := []string{
+		"https://example.com/a#top",
+		"https://example.com/a/",
+		"https://example.com/copy-of-a",
+	}
+	assert.Equal(t, expected, agg.Aliases("https://example.com/a"))
+
+	// Aliases survive a checkpoint round trip
+	restored, err := New(&config.Config{})
+	require.NoError(t, err)
+	restored.RestorePages(agg.Pages())
+	assert.Equal(t, expected, restored.Aliases("https://example.com/a"))
+}
+
+func TestNavigationOrder(t *testing.T) {
+	tempFile := filepath.Join(t.TempDir(), "output.md")
+
+	cfg := &config.Config{
+		Name:       "Test Documentation",
+		OutputFile: tempFile,
+		Processing: config.ProcessingConfig{
+			GenerateTOC: true,
+		},
+	}
+
+	agg, err := New(cfg)
+	require.NoError(t, err)
+
+	agg.AddPage("https://example.com/advanced", "Advanced", "Advanced content", 1)
+	agg.AddPage("https://example.com/install", "Installation", "Install content", 2)
+	agg.AddPage("https://example.com/config", "Configuration", "Config content", 2)
+	agg.AddPage("https://example.com/changelog", "Changelog", "Changelog content", 2)
+	agg.AddAlias("https://example.com/install", "https://example.com/setup")
+
+	agg.AddNavigation([]*NavItem{
+		{
+			Title: "Getting Started",
+			Children: []*NavItem{
+				{Title: "Install", URL: "https://example.com/setup"},
+				{Title: "Configuration", URL: "https://example.com/config"},
+				{Title: "Not crawled", URL: "https://example.com/missing"},
+			},
+		},
+		{Title: "Advanced", URL: "https://example.com/advanced"},
+		{Title: "Empty section", Children: []*NavItem{{Title: "Gone", URL: "https://example.com/gone"}}},
+		// Pages are placed at their first entry only
+		{Title: "Install again", URL: "https://example.com/install"},
+	})
+
+	require.NoError(t, agg.GenerateOutput())
+	content, err := os.ReadFile(tempFile)
+	require.NoError(t, err)
+	output := string(content)
+
+	expectedTOC := "## Table of Contents\n\n" +
+		"  - [Getting Started](#getting-started)\n" +
+		"    - [Installation](#installation)\n" +
+		"    - [Configuration](#configuration)\n" +
+		"  - [Advanced](#advanced)\n" +
+		"  - [Appendix](#appendix)\n" +
+		"    - [Changelog](#changelog)\n"
+	assert.Contains(t, output, expectedTOC)
+	assert.NotContains(t, output, "Not crawled")
+	assert.NotContains(t, output, "Empty section")
+
+	headings := []string{
+		"## Getting Started\n\n### Installation",
+		"### Configuration",
+		"## Advanced",
+		"## Appendix\n\n### Changelog",
+	}
+	last := 0
+	for _, heading := range headings {
+		index := strings.Index(output, heading)
+		require.NotEqual(t, -1, index, heading)
+		assert.Greater(t, index, last, heading)
+		last = index
+	}
+}
+
+func TestIncompleteOutput(t *testing.T) {
+	tempFile := t.TempDir() + "/incomplete.md"
+	cfg := &config.Config{
+		Name:       "Test Documentation",
+		OutputFile: tempFile,
+		Output: config.OutputConfig{
+			IncludeMetadata: true,
+		},
+	}
+
+	agg, err := New(cfg)
+	require.NoError(t, err)
+	agg.AddPage("https://example.com/", "Home", "Welcome", 1)
+	agg.SetIncomplete("The crawl was cancelled", []string{"https://example.com/a", "https://example.com/b"})
+	require.NoError(t, agg.GenerateOutput())
+
+	content, err := os.ReadFile(tempFile)
+	require.NoError(t, err)
+	output := string(content)
+
+	banner := "---\n\n> **Incomplete:** The crawl was cancelled. 2 queued URLs were not visited.\n\n"
+	assert.Contains(t, output, banner)
+	assert.True(t, strings.HasSuffix(output, "## Unvisited URLs\n\n- https://example.com/a\n- https://example.com/b\n\n"))
+}
+
+func TestNestHeadings(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		titleLevel int
+		offset     int
+		expected   string
+	}{
+		{
+			name:       "duplicate title dropped",
+			content:    "# Getting Started\n\nIntro\n\n## Install\n\n### From source",
+			titleLevel: 1,
+			offset:     1,
+			expected:   "Intro\n\n## Install\n\n### From source",
+		},
+		{
+			name:       "top headings go below the title",
+			content:    "Intro\n\n# Install\n\n## From source",
+			titleLevel: 2,
+			offset:     1,
+			expected:   "Intro\n\n### Install\n\n#### From source",
+		},
+		{
+			name:       "duplicate title with emphasis and metadata comment",
+			content:    "<!-- title: x -->\n\n# *Getting* [Started](/start)\n\nIntro",
+			titleLevel: 1,
+			offset:     1,
+			expected:   "<!-- title: x -->\n\nIntro",
+		},
+		{
+			name:       "title later in the page is kept",
+			content:    "Intro\n\n# Getting Started",
+			titleLevel: 1,
+			offset:     1,
+			expected:   "Intro\n\n## Getting Started",
+		},
+		{
+			name:       "past H6 becomes bold",
+			content:    "# A\n\n## B\n\n### C",
+			titleLevel: 4,
+			offset:     1,
+			expected:   "##### A\n\n###### B\n\n**C**",
+		},
+		{
+			name:       "larger offset",
+			content:    "## A\n\n### B",
+			titleLevel: 1,
+			offset:     2,
+			expected:   "### A\n\n#### B",
+		},
+		{
+			name:       "zero offset keeps top headings level with the title",
+			content:    "Intro\n\n## Install\n\n### From source",
+			titleLevel: 2,
+			offset:     0,
+			expected:   "Intro\n\n## Install\n\n### From source",
+		},
+		{
+			name:       "fenced code left alone",
+			content:    "## A\n\n```bash\n# comment\n```",
+			titleLevel: 1,
+			offset:     1,
+			expected:   "## A\n\n```bash\n# comment\n```",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, nestHeadings(tt.content, "Getting Started", tt.titleLevel, tt.offset))
+		})
+	}
+}
+
+func TestInternalLinks(t *testing.T) {
+	tempFile := t.TempDir() + "/links.md"
+	cfg := &config.Config{
+		Name:       "Test Docs",
+		OutputFile: tempFile,
+		Output: config.OutputConfig{
+			IncludeMetadata: true,
+		},
+		Processing: config.ProcessingConfig{
+			GenerateTOC: true,
+		},
+	}
+
+	agg, err := New(cfg)
+	require.NoError(t, err)
+	agg.SetURLCanonicalizer(func(u string) string { return strings.TrimSuffix(u, "/") })
+
+	agg.AddPage("https://example.com/home", "Home", "See [the guide](https://example.com/guide#install-steps), "+
+		"[the API](https://example.com/api/), [the old guide](https://example.com/guide.html) "+
+		"and [elsewhere](https://other.com/x).\n\n![Logo](https://example.com/guide)\n\n"+
+		"```\n[code](https://example.com/guide)\n```", 1)
+	agg.AddPage("https://example.com/guide", "Guide", "## Install steps\n\nText\n\n## Setup\n\nMore", 1)
+	agg.AddPage("https://example.com/api", "Setup", "Back to [the guide](https://example.com/guide#Setup)", 1)
+	agg.AddAlias("https://example.com/guide", "https://example.com/guide.html")
+	require.NoError(t, agg.GenerateOutput())
+
+	content, err := os.ReadFile(tempFile)
+	require.NoError(t, err)
+	output := string(content)
+
+	assert.Contains(t, output, "[the guide](#install-steps)")
+	assert.Contains(t, output, "[the old guide](#guide)")
+	// The Setup page comes first, so the guide's Setup heading gets setup-1
+	assert.Contains(t, output, "[the API](#setup)")
+	assert.Contains(t, output, "- [Setup](#setup)")
+	assert.Contains(t, output, "Back to [the guide](#setup-1)")
+	assert.Contains(t, output, "[elsewhere](https://other.com/x)")
+	assert.Contains(t, output, "![Logo](https://example.com/guide)")
+	assert.Contains(t, output, "```\n[code](https://example.com/guide)\n```")
+
+	internal, external := agg.LinkStats()
+	assert.Equal(t, 4, internal)
+	assert.Equal(t, 1, external)
+}
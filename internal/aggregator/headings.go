@@ -0,0 +1,102 @@
+package aggregator
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/vladkampov/markdocify/internal/codefence"
+)
+
+var (
+	// atxHeadingRe matches an ATX heading with its level marker and text,
+	// without the optional closing #s
+	atxHeadingRe = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	// markdownLinkRe matches an inline link, keeping its text
+	markdownLinkRe = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+)
+
+// nestHeadings places the headings of a page's markdown under its title at
+// titleLevel: the page's top headings go offset levels below the title and
+// the rest keep their place relative to them. Headings pushed past H6 become
+// bold text. A leading heading repeating the title is dropped. Fenced code
+// is left alone.
+func nestHeadings(content, title string, titleLevel, offset int) string {
+	lines := strings.Split(content, "\n")
+
+	type heading struct {
+		line  int
+		level int
+		text  string
+	}
+	var headings []heading
+	duplicate := -1
+	leading := true
+	fence := ""
+	for i, line := range lines {
+		if fence != "" {
+			if codefence.IsClosing(line, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if fence = codefence.Opening(line); fence != "" {
+			leading = false
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if match := atxHeadingRe.FindStringSubmatch(line); match != nil {
+			if leading && sameTitle(match[2], title) {
+				duplicate = i
+			} else {
+				headings = append(headings, heading{line: i, level: len(match[1]), text: match[2]})
+			}
+			leading = false
+			continue
+		}
+		// Converter metadata comments come before the page's own content
+		if trimmed != "" && !(strings.HasPrefix(trimmed, "<!--") && strings.HasSuffix(trimmed, "-->")) {
+			leading = false
+		}
+	}
+
+	top := 6
+	for _, h := range headings {
+		if h.level < top {
+			top = h.level
+		}
+	}
+	shift := titleLevel + offset - top
+
+	for _, h := range headings {
+		level := h.level + shift
+		switch {
+		case h.text == "":
+			lines[h.line] = ""
+		case level > 6:
+			lines[h.line] = "**" + h.text + "**"
+		default:
+			lines[h.line] = strings.Repeat("#", level) + " " + h.text
+		}
+	}
+
+	if duplicate >= 0 {
+		end := duplicate + 1
+		if end < len(lines) && strings.TrimSpace(lines[end]) == "" {
+			end++
+		}
+		lines = append(lines[:duplicate], lines[end:]...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// sameTitle reports whether heading text says the same as the page title,
+// ignoring case, emphasis and links.
+func sameTitle(text, title string) bool {
+	normalize := func(s string) string {
+		s = markdownLinkRe.ReplaceAllString(s, "$1")
+		s = strings.NewReplacer("*", "", "_", "", "`", "", "\\", "").Replace(s)
+		return strings.ToLower(strings.Join(strings.Fields(s), " "))
+	}
+	return title != "" && normalize(text) == normalize(title)
+}
@@ -0,0 +1,218 @@
+package aggregator
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/vladkampov/markdocify/internal/codefence"
+)
+
+// inlineLinkRe matches an inline link or image with its destination and
+// optional title. Link text may hold one level of brackets, as in a linked
+// image.
+var inlineLinkRe = regexp.MustCompile(`(!?)\[((?:\\.|[^\[\]\\]|\[(?:\\.|[^\[\]\\])*\](?:\([^)]*\))?)*)\]\(([^()\s]+)((?:\s+"(?:\\.|[^"\\])*")?)\)`)
+
+// section is an outline entry as written to the output: its heading
+// title, the anchor of that heading and, for pages, the page content with
+// its headings nested under the title.
+type section struct {
+	outlineEntry
+	anchor  string
+	content string
+}
+
+// anchorIndex finds the output anchor of every page and of the headings
+// inside it.
+type anchorIndex struct {
+	// pages maps page URLs and their aliases to the URL the page was
+	// aggregated at
+	pages map[string]string
+	// anchors maps a page URL to the anchor of its title
+	anchors map[string]string
+	// headings maps a page URL to the anchors of its headings, keyed by
+	// the anchor each heading would have on its own
+	headings map[string]map[string]string
+}
+
+// anchorCounter numbers repeated anchors like GitHub does: the second
+// "setup" heading is "setup-1", the third "setup-2".
+type anchorCounter map[string]int
+
+func (c anchorCounter) unique(anchor string) string {
+	n := c[anchor]
+	c[anchor]++
+	if n == 0 {
+		return anchor
+	}
+	return fmt.Sprintf("%s-%d", anchor, n)
+}
+
+// SetURLCanonicalizer sets how link targets are normalized before they
+// are matched against page URLs, which are canonical already.
+func (a *Aggregator) SetURLCanonicalizer(canonicalize func(string) string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.canonicalize = canonicalize
+}
+
+// LinkStats returns how many links of the last output pointed at an
+// aggregated page and were turned into anchors, and how many were left
+// pointing elsewhere.
+func (a *Aggregator) LinkStats() (internal, external int) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.internalLinks, a.externalLinks
+}
+
+// sections lays out the output: the outline with final titles, nested page
+// content and the anchors every heading gets, counted in document order
+// from the headings written before the first page.
+func (a *Aggregator) sections(preceding ...string) ([]section, *anchorIndex) {
+	counter := make(anchorCounter)
+	for _, heading := range preceding {
+		counter.unique(a.createAnchor(heading))
+	}
+
+	index := &anchorIndex{
+		pages:    make(map[string]string),
+		anchors:  make(map[string]string),
+		headings: make(map[string]map[string]string),
+	}
+
+	var sections []section
+	for _, entry := range a.outline() {
+		headingLevel := entry.level + 1
+		if headingLevel > 6 {
+			headingLevel = 6
+		}
+
+		title := entry.title
+		if entry.page != nil && (title == "" || title == "Untitled") {
+			title = a.extractTitleFromURL(entry.page.URL)
+		}
+		entry.title = title
+
+		s := section{outlineEntry: entry, anchor: counter.unique(a.createAnchor(title))}
+		if page := entry.page; page != nil {
+			s.content = strings.TrimSpace(nestHeadings(page.Content, title, headingLevel, a.config.Output.HeadingLevels()))
+
+			index.pages[page.URL] = page.URL
+			index.anchors[page.URL] = s.anchor
+			for _, alias := range a.aliasesOf(page.URL) {
+				if _, taken := index.pages[alias]; !taken {
+					index.pages[alias] = page.URL
+				}
+			}
+
+			headings := make(map[string]string)
+			for _, text := range headingTexts(s.content) {
+				own := a.createAnchor(text)
+				anchor := counter.unique(own)
+				if _, seen := headings[own]; !seen {
+					headings[own] = anchor
+				}
+			}
+			index.headings[page.URL] = headings
+		}
+		sections = append(sections, s)
+	}
+	return sections, index
+}
+
+// headingTexts returns the text of the ATX headings outside fenced code.
+func headingTexts(content string) []string {
+	var texts []string
+	fence := ""
+	for _, line := range strings.Split(content, "\n") {
+		if fence != "" {
+			if codefence.IsClosing(line, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if fence = codefence.Opening(line); fence != "" {
+			continue
+		}
+		if match := atxHeadingRe.FindStringSubmatch(line); match != nil && match[2] != "" {
+			texts = append(texts, match[2])
+		}
+	}
+	return texts
+}
+
+// internalizeLinks points the links of a page's content that lead to an
+// aggregated page at that page's anchor, or at the anchor of the heading
+// their fragment names. Images and fenced code are left alone.
+func (a *Aggregator) internalizeLinks(content string, index *anchorIndex) string {
+	lines := strings.Split(content, "\n")
+	fence := ""
+	for i, line := range lines {
+		if fence != "" {
+			if codefence.IsClosing(line, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if fence = codefence.Opening(line); fence != "" {
+			continue
+		}
+		if !strings.Contains(line, "](") {
+			continue
+		}
+
+		lines[i] = inlineLinkRe.ReplaceAllStringFunc(line, func(link string) string {
+			match := inlineLinkRe.FindStringSubmatch(link)
+			if match[1] == "!" {
+				return link
+			}
+			anchor, ok := a.resolveAnchor(match[3], index)
+			if !ok {
+				if isWebURL(match[3]) {
+					a.externalLinks++
+				}
+				return link
+			}
+			a.internalLinks++
+			return "[" + match[2] + "](#" + anchor + match[4] + ")"
+		})
+	}
+	return strings.Join(lines, "\n")
+}
+
+// resolveAnchor returns the output anchor a link target leads to.
+func (a *Aggregator) resolveAnchor(target string, index *anchorIndex) (string, bool) {
+	if !isWebURL(target) {
+		return "", false
+	}
+	pageURL, fragment, _ := strings.Cut(target, "#")
+	if a.canonicalize != nil {
+		pageURL = a.canonicalize(pageURL)
+	}
+
+	page, ok := index.pages[pageURL]
+	if !ok {
+		return "", false
+	}
+	if fragment == "" {
+		return index.anchors[page], true
+	}
+
+	if decoded, err := url.PathUnescape(fragment); err == nil {
+		fragment = decoded
+	}
+	for _, key := range []string{strings.ToLower(fragment), a.createAnchor(fragment)} {
+		if heading, ok := index.headings[page][key]; ok {
+			return heading, true
+		}
+	}
+	return index.anchors[page], true
+}
+
+// isWebURL reports whether target is an absolute URL a page could be
+// crawled from.
+func isWebURL(target string) bool {
+	return strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") ||
+		strings.HasPrefix(target, "file://")
+}
@@ -0,0 +1,103 @@
+package aggregator
+
+// AppendixTitle heads the pages that are not linked from the navigation.
+const AppendixTitle = "Appendix"
+
+// NavItem is an entry of a site's navigation tree. Section headings that
+// do not link to a page have an empty URL.
+type NavItem struct {
+	Title    string     `json:"title"`
+	URL      string     `json:"url,omitempty"`
+	Children []*NavItem `json:"children,omitempty"`
+}
+
+// outlineEntry is a heading of the output: either a page or a navigation
+// section without a page of its own. Top-level entries have level 1.
+type outlineEntry struct {
+	title string
+	page  *Page
+	level int
+}
+
+// AddNavigation adds a navigation tree captured from a start page. When
+// navigation is present, pages are ordered by the first entry linking to
+// them and the remaining pages go to a trailing appendix.
+func (a *Aggregator) AddNavigation(items []*NavItem) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.navigation = append(a.navigation, items...)
+}
+
+// Navigation returns the navigation trees added so far.
+func (a *Aggregator) Navigation() []*NavItem {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return append([]*NavItem(nil), a.navigation...)
+}
+
+// outline returns the output headings in order. Pages must be sorted.
+func (a *Aggregator) outline() []outlineEntry {
+	entries := make([]outlineEntry, 0, len(a.pages))
+	if len(a.navigation) == 0 {
+		for _, page := range a.pages {
+			entries = append(entries, outlineEntry{title: page.Title, page: page, level: page.Depth})
+		}
+		return entries
+	}
+
+	byURL := make(map[string]*Page, len(a.pages))
+	for _, page := range a.pages {
+		byURL[page.URL] = page
+	}
+	for _, page := range a.pages {
+		for alias := range a.aliases[page.URL] {
+			if _, ok := byURL[alias]; !ok {
+				byURL[alias] = page
+			}
+		}
+	}
+
+	placed := make(map[*Page]bool)
+	entries = append(entries, a.navOutline(a.navigation, 1, byURL, placed)...)
+
+	var appendix []outlineEntry
+	for _, page := range a.pages {
+		if !placed[page] {
+			appendix = append(appendix, outlineEntry{title: page.Title, page: page, level: 2})
+		}
+	}
+	if len(appendix) > 0 {
+		entries = append(entries, outlineEntry{title: AppendixTitle, level: 1})
+		entries = append(entries, appendix...)
+	}
+
+	return entries
+}
+
+// navOutline walks items depth-first. Each page is placed at its first
+// entry, and entries with neither a page nor placed children are dropped.
+func (a *Aggregator) navOutline(items []*NavItem, level int, byURL map[string]*Page, placed map[*Page]bool) []outlineEntry {
+	var entries []outlineEntry
+	for _, item := range items {
+		page := byURL[item.URL]
+		if page != nil && placed[page] {
+			page = nil
+		}
+		if page != nil {
+			placed[page] = true
+		}
+
+		children := a.navOutline(item.Children, level+1, byURL, placed)
+		if page == nil && len(children) == 0 {
+			continue
+		}
+
+		title := item.Title
+		if page != nil && (title == "" || page.Title != "" && page.Title != "Untitled") {
+			title = page.Title
+		}
+		entries = append(entries, outlineEntry{title: title, page: page, level: level})
+		entries = append(entries, children...)
+	}
+	return entries
+}
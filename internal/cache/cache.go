@@ -0,0 +1,228 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Entry is what is remembered about a page between runs.
+type Entry struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	ContentHash  string    `json:"content_hash"`
+	Title        string    `json:"title"`
+	Markdown     string    `json:"markdown"`
+	Links        []string  `json:"links,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+// Status describes how a page compares with the previous run.
+type Status string
+
+const (
+	StatusFresh   Status = "fresh"
+	StatusChanged Status = "changed"
+	StatusNew     Status = "new"
+)
+
+// Stats summarizes a run against the previous one.
+type Stats struct {
+	Fresh   int
+	Changed int
+	New     int
+	Gone    int
+}
+
+// Cache is a persistent per-URL page cache for one configuration.
+type Cache struct {
+	path string
+
+	mu       sync.Mutex
+	previous map[string]*Entry
+	current  map[string]*Entry
+	stats    Stats
+}
+
+// Open loads the cache for the named configuration from dir. A missing
+// cache file is not an error; every page will then be reported as new.
+func Open(dir, name string) (*Cache, error) {
+	c := &Cache{
+		path:     filepath.Join(dir, fileName(name)),
+		previous: make(map[string]*Entry),
+		current:  make(map[string]*Entry),
+	}
+
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache: %w", err)
+	}
+
+	var entries []*Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse cache %s: %w", c.path, err)
+	}
+	for _, entry := range entries {
+		c.previous[entry.URL] = entry
+	}
+
+	return c, nil
+}
+
+// Path returns the file the cache is stored in.
+func (c *Cache) Path() string {
+	return c.path
+}
+
+// Lookup returns the entry stored for url by the previous run.
+func (c *Cache) Lookup(url string) (*Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.previous[url]
+	return entry, ok
+}
+
+// Reuse records that url was confirmed unchanged (for example by a 304
+// response) and returns its previous entry.
+func (c *Cache) Reuse(url string) (*Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.previous[url]
+	if !ok {
+		return nil, false
+	}
+	if _, seen := c.current[url]; !seen {
+		c.current[url] = entry
+		c.stats.Fresh++
+	}
+	return entry, true
+}
+
+// Store records a freshly fetched page and reports how it compares with
+// the previous run.
+func (c *Cache) Store(entry *Entry) Status {
+	entry.ContentHash = Hash(entry.Markdown)
+	if entry.FetchedAt.IsZero() {
+		entry.FetchedAt = time.Now()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status := StatusNew
+	if previous, ok := c.previous[entry.URL]; ok {
+		status = StatusChanged
+		if previous.ContentHash == entry.ContentHash {
+			status = StatusFresh
+		}
+	}
+
+	if _, seen := c.current[entry.URL]; !seen {
+		switch status {
+		case StatusFresh:
+			c.stats.Fresh++
+		case StatusChanged:
+			c.stats.Changed++
+		default:
+			c.stats.New++
+		}
+	}
+	c.current[entry.URL] = entry
+
+	return status
+}
+
+// Stats returns the comparison with the previous run. Pages cached last
+// time but not seen in this run are counted as gone.
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	for url := range c.previous {
+		if _, ok := c.current[url]; !ok {
+			stats.Gone++
+		}
+	}
+	return stats
+}
+
+// Save writes the cache to disk. With prune set, pages not seen in this
+// run are dropped; otherwise they are kept for the next run.
+func (c *Cache) Save(prune bool) error {
+	c.mu.Lock()
+	entries := make([]*Entry, 0, len(c.current))
+	for _, entry := range c.current {
+		entries = append(entries, entry)
+	}
+	if !prune {
+		for url, entry := range c.previous {
+			if _, ok := c.current[url]; !ok {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	c.mu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].URL < entries[j].URL
+	})
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0750); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache: %w", err)
+	}
+
+	tmpPath := c.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	if err := os.Rename(tmpPath, c.path); err != nil {
+		return fmt.Errorf("failed to replace cache: %w", err)
+	}
+
+	return nil
+}
+
+// Hash returns the content hash used to detect changed pages.
+func Hash(content string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(content)))
+}
+
+// fileName turns a configuration name into a safe file name.
+func fileName(name string) string {
+	var b strings.Builder
+	lastDash := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			lastDash = false
+		} else if !lastDash && b.Len() > 0 {
+			b.WriteRune('-')
+			lastDash = true
+		}
+	}
+
+	slug := strings.Trim(b.String(), "-")
+	if slug == "" {
+		slug = "default"
+	}
+	return slug + ".json"
+}
@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpen_MissingFile(t *testing.T) {
+	c, err := Open(t.TempDir(), "My Docs")
+	require.NoError(t, err)
+
+	_, ok := c.Lookup("https://example.com/")
+	assert.False(t, ok)
+	assert.Equal(t, Stats{}, c.Stats())
+}
+
+func TestStoreAndReuse(t *testing.T) {
+	dir := t.TempDir()
+
+	first, err := Open(dir, "My Docs")
+	require.NoError(t, err)
+	assert.Equal(t, StatusNew, first.Store(&Entry{URL: "https://example.com/a", ETag: `"a1"`, Markdown: "A"}))
+	assert.Equal(t, StatusNew, first.Store(&Entry{URL: "https://example.com/b", Markdown: "B"}))
+	assert.Equal(t, StatusNew, first.Store(&Entry{URL: "https://example.com/c", Markdown: "C"}))
+	require.NoError(t, first.Save(true))
+
+	second, err := Open(dir, "My Docs")
+	require.NoError(t, err)
+
+	entry, ok := second.Lookup("https://example.com/a")
+	require.True(t, ok)
+	assert.Equal(t, `"a1"`, entry.ETag)
+
+	reused, ok := second.Reuse("https://example.com/a")
+	require.True(t, ok)
+	assert.Equal(t, "A", reused.Markdown)
+
+	assert.Equal(t, StatusChanged, second.Store(&Entry{URL: "https://example.com/b", Markdown: "B2"}))
+	assert.Equal(t, StatusNew, second.Store(&Entry{URL: "https://example.com/d", Markdown: "D"}))
+
+	assert.Equal(t, Stats{Fresh: 1, Changed: 1, New: 1, Gone: 1}, second.Stats())
+
+	_, ok = second.Reuse("https://example.com/unknown")
+	assert.False(t, ok)
+}
+
+func TestStore_SameContentIsFresh(t *testing.T) {
+	dir := t.TempDir()
+
+	first, err := Open(dir, "docs")
+	require.NoError(t, err)
+	first.Store(&Entry{URL: "https://example.com/a", Markdown: "A"})
+	require.NoError(t, first.Save(true))
+
+	second, err := Open(dir, "docs")
+	require.NoError(t, err)
+	assert.Equal(t, StatusFresh, second.Store(&Entry{URL: "https://example.com/a", Markdown: "A"}))
+	assert.Equal(t, Stats{Fresh: 1}, second.Stats())
+}
+
+func TestSave_Prune(t *testing.T) {
+	dir := t.TempDir()
+
+	first, err := Open(dir, "docs")
+	require.NoError(t, err)
+	first.Store(&Entry{URL: "https://example.com/a", Markdown: "A"})
+	first.Store(&Entry{URL: "https://example.com/b", Markdown: "B"})
+	require.NoError(t, first.Save(true))
+
+	partial, err := Open(dir, "docs")
+	require.NoError(t, err)
+	partial.Store(&Entry{URL: "https://example.com/a", Markdown: "A"})
+	require.NoError(t, partial.Save(false))
+
+	kept, err := Open(dir, "docs")
+	require.NoError(t, err)
+	_, ok := kept.Lookup("https://example.com/b")
+	assert.True(t, ok, "Unseen pages are kept when not pruning")
+
+	kept.Store(&Entry{URL: "https://example.com/a", Markdown: "A"})
+	require.NoError(t, kept.Save(true))
+
+	pruned, err := Open(dir, "docs")
+	require.NoError(t, err)
+	_, ok = pruned.Lookup("https://example.com/b")
+	assert.False(t, ok, "Unseen pages are dropped when pruning")
+}
+
+func TestFileName(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Stripe API Documentation", "stripe-api-documentation.json"},
+		{"  Next.js / Docs  ", "next-js-docs.json"},
+		{"../../etc", "etc.json"},
+		{"", "default.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.expected, fileName(tt.input))
+		})
+	}
+}
@@ -0,0 +1,27 @@
+// Package codefence finds fenced code blocks in markdown, for the
+// converter and the aggregator to leave them alone.
+package codefence
+
+import "strings"
+
+// Opening returns the fence a line opens a fenced code block with,
+// or "" if it does not open one.
+func Opening(line string) string {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 {
+		return ""
+	}
+	for _, char := range []string{"`", "~"} {
+		fence := trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, char))]
+		if len(fence) >= 3 {
+			return fence
+		}
+	}
+	return ""
+}
+
+// IsClosing reports whether line closes a block opened with fence.
+func IsClosing(line, fence string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == ""
+}
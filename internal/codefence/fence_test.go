@@ -0,0 +1,33 @@
+package codefence
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpening(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected string
+	}{
+		{"```go", "```"},
+		{"~~~~", "~~~~"},
+		{"   ```", "```"},
+		{"    ```", ""},
+		{"``", ""},
+		{"text ```", ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, Opening(tt.line), tt.line)
+	}
+}
+
+func TestIsClosing(t *testing.T) {
+	assert.True(t, IsClosing("```", "```"))
+	assert.True(t, IsClosing("  `````  ", "```"))
+	assert.False(t, IsClosing("``", "```"))
+	assert.False(t, IsClosing("```go", "```"))
+	assert.False(t, IsClosing("~~~", "```"))
+}
@@ -1,10 +1,15 @@
 package config
 
 import (
+	"crypto/tls"
 	"fmt"
 	"net/url"
 	"os"
+	"path/filepath"
 	"regexp"
+	"runtime"
+	"sort"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -14,18 +19,37 @@ type Config struct {
 	Name       string `yaml:"name" validate:"required"`
 	BaseURL    string `yaml:"base_url" validate:"required,url"`
 	OutputFile string `yaml:"output_file" validate:"required"`
+	// ReportFile, if set, receives a JSON report of every URL seen.
+	ReportFile string `yaml:"report_file"`
+	// WARCFile, if set, receives every HTTP request and response made
+	// during the crawl. Names ending in .gz are compressed.
+	WARCFile string `yaml:"warc_file"`
+
+	Source SourceConfig `yaml:"source"`
 
 	StartURLs      []string `yaml:"start_urls" validate:"required,min=1"`
 	FollowPatterns []string `yaml:"follow_patterns"`
 	IgnorePatterns []string `yaml:"ignore_patterns"`
 
-	Selectors SelectorConfig `yaml:"selectors"`
+	SkipCategories SkipCategoriesConfig `yaml:"skip_categories"`
+
+	Sitemaps     SitemapConfig   `yaml:"sitemaps"`
+	Canonicalize CanonicalConfig `yaml:"canonicalize"`
+	Throttle     ThrottleConfig  `yaml:"throttle"`
+	Retry        RetryConfig     `yaml:"retry"`
+	Requests     RequestsConfig  `yaml:"requests"`
+	Network      NetworkConfig   `yaml:"network"`
+
+	Selectors  SelectorConfig   `yaml:"selectors"`
+	TitleRules TitleRulesConfig `yaml:"title_rules"`
 
 	Processing ProcessingConfig `yaml:"processing"`
 	Engines    []EngineConfig   `yaml:"engines"`
 	Output     OutputConfig     `yaml:"output"`
 	Security   SecurityConfig   `yaml:"security"`
 	Monitoring MonitoringConfig `yaml:"monitoring"`
+	State      StateConfig      `yaml:"state"`
+	Cache      CacheConfig      `yaml:"cache"`
 }
 
 type SelectorConfig struct {
@@ -35,13 +59,653 @@ type SelectorConfig struct {
 	Exclude    []string `yaml:"exclude"`
 }
 
+// Sources a page title can be taken from.
+const (
+	TitleSourceSelector = "selector"
+	TitleSourceTitle    = "title"
+	TitleSourceOGTitle  = "og_title"
+	TitleSourceURL      = "url"
+)
+
+// DefaultTitleFallback is the order title sources are tried in.
+var DefaultTitleFallback = []string{TitleSourceSelector, TitleSourceTitle, TitleSourceOGTitle, TitleSourceURL}
+
+// DefaultTitlePresets apply when title_rules.presets is not set.
+var DefaultTitlePresets = []string{"docs"}
+
+// TitlePresets are named title rules for common documentation sites.
+var TitlePresets = map[string]TitleRulesConfig{
+	"docs": {
+		StripSuffixes: []string{`\|\s*.*Documentation`, `\|\s*.*Docs`},
+	},
+	"react": {
+		StripSuffixes: []string{`[–|-]\s*React`},
+		Replacements: []TitleReplacement{
+			{Pattern: `\s*This feature is available in the latest.*?React\s*`},
+			{Pattern: `\s*This feature is available in the latest Canary\s*`},
+			{Pattern: `\s*This feature is available in the latest Experimental version of React\s*`},
+		},
+	},
+	"stripe": {
+		StripSuffixes: []string{`[–|-]\s*Stripe`},
+	},
+}
+
+// TitleRulesConfig controls how page titles are chosen and cleaned. All
+// patterns are case-insensitive regular expressions.
+type TitleRulesConfig struct {
+	// Presets names entries of TitlePresets to apply before the rules below.
+	Presets       []string           `yaml:"presets"`
+	StripPrefixes []string           `yaml:"strip_prefixes"`
+	StripSuffixes []string           `yaml:"strip_suffixes"`
+	Replacements  []TitleReplacement `yaml:"replacements"`
+	// Fallback lists the title sources to try, in order.
+	Fallback []string `yaml:"fallback"`
+}
+
+// TitleReplacement replaces every match of Pattern in a title.
+type TitleReplacement struct {
+	Pattern     string `yaml:"pattern"`
+	Replacement string `yaml:"replacement"`
+}
+
+// Expand returns the rules with presets merged in, preset rules first.
+func (c TitleRulesConfig) Expand() TitleRulesConfig {
+	presets := c.Presets
+	if presets == nil {
+		presets = DefaultTitlePresets
+	}
+
+	var expanded TitleRulesConfig
+	for _, name := range presets {
+		preset := TitlePresets[name]
+		expanded.StripPrefixes = append(expanded.StripPrefixes, preset.StripPrefixes...)
+		expanded.StripSuffixes = append(expanded.StripSuffixes, preset.StripSuffixes...)
+		expanded.Replacements = append(expanded.Replacements, preset.Replacements...)
+	}
+	expanded.StripPrefixes = append(expanded.StripPrefixes, c.StripPrefixes...)
+	expanded.StripSuffixes = append(expanded.StripSuffixes, c.StripSuffixes...)
+	expanded.Replacements = append(expanded.Replacements, c.Replacements...)
+
+	expanded.Fallback = c.Fallback
+	if expanded.Fallback == nil {
+		expanded.Fallback = DefaultTitleFallback
+	}
+	return expanded
+}
+
+// TitlePrefixPattern returns the regular expression removing pattern from
+// the start of a title.
+func TitlePrefixPattern(pattern string) string {
+	return `(?i)^\s*(?:` + pattern + `)\s*`
+}
+
+// TitleSuffixPattern returns the regular expression removing pattern from
+// the end of a title.
+func TitleSuffixPattern(pattern string) string {
+	return `(?i)\s*(?:` + pattern + `)\s*$`
+}
+
+func (c TitleRulesConfig) validate() error {
+	for i, name := range c.Presets {
+		if _, ok := TitlePresets[name]; !ok {
+			return fmt.Errorf("unknown title_rules.presets[%d] '%s'", i, name)
+		}
+	}
+
+	for i, pattern := range c.StripPrefixes {
+		if _, err := regexp.Compile(TitlePrefixPattern(pattern)); err != nil {
+			return fmt.Errorf("invalid title_rules.strip_prefixes[%d] '%s': %w", i, pattern, err)
+		}
+	}
+	for i, pattern := range c.StripSuffixes {
+		if _, err := regexp.Compile(TitleSuffixPattern(pattern)); err != nil {
+			return fmt.Errorf("invalid title_rules.strip_suffixes[%d] '%s': %w", i, pattern, err)
+		}
+	}
+	for i, replacement := range c.Replacements {
+		if replacement.Pattern == "" {
+			return fmt.Errorf("title_rules.replacements[%d] pattern cannot be empty", i)
+		}
+		if _, err := regexp.Compile("(?i)" + replacement.Pattern); err != nil {
+			return fmt.Errorf("invalid title_rules.replacements[%d] '%s': %w", i, replacement.Pattern, err)
+		}
+	}
+
+	for i, source := range c.Fallback {
+		switch source {
+		case TitleSourceSelector, TitleSourceTitle, TitleSourceOGTitle, TitleSourceURL:
+		default:
+			return fmt.Errorf("invalid title_rules.fallback[%d] '%s': must be one of %v", i, source, DefaultTitleFallback)
+		}
+	}
+
+	return nil
+}
+
+// Where pages are read from.
+const (
+	SourceTypeHTTP       = "http"
+	SourceTypeFilesystem = "filesystem"
+	SourceTypeWARC       = "warc"
+)
+
+// SourceConfig selects where pages come from. The filesystem source reads
+// a local directory of HTML files, such as a Sphinx build or a wget mirror,
+// instead of fetching pages over HTTP. The warc source replays a WARC file
+// written through warc_file, without any network access.
+type SourceConfig struct {
+	// Type is "http" (default), "filesystem" or "warc".
+	Type string `yaml:"type"`
+	// Root is the directory served by the filesystem source.
+	Root string `yaml:"root"`
+	// Path is the WARC file replayed by the warc source.
+	Path string `yaml:"path"`
+	// BaseURL is the URL the root directory is published at, so that
+	// patterns and source links use the site's real URLs. It defaults to
+	// DefaultFilesystemBaseURL.
+	BaseURL string `yaml:"base_url"`
+}
+
+// DefaultFilesystemBaseURL maps the source root to file:///, so that URLs
+// and patterns see paths relative to the root rather than the directory's
+// location on disk.
+const DefaultFilesystemBaseURL = "file:///"
+
+// IsFilesystem reports whether pages are read from a local directory.
+func (s SourceConfig) IsFilesystem() bool {
+	return s.Type == SourceTypeFilesystem
+}
+
+// IsWARC reports whether pages are replayed from a WARC file.
+func (s SourceConfig) IsWARC() bool {
+	return s.Type == SourceTypeWARC
+}
+
+func (s SourceConfig) validate() error {
+	switch s.Type {
+	case "", SourceTypeHTTP:
+		return nil
+	case SourceTypeFilesystem:
+	case SourceTypeWARC:
+		return s.validateWARC()
+	default:
+		return fmt.Errorf("source.type must be %q, %q or %q, got %q", SourceTypeHTTP, SourceTypeFilesystem, SourceTypeWARC, s.Type)
+	}
+
+	if s.Root == "" {
+		return fmt.Errorf("source.root is required for the filesystem source")
+	}
+	info, err := os.Stat(s.Root)
+	if err != nil {
+		return fmt.Errorf("invalid source.root: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("source.root must be a directory: '%s'", s.Root)
+	}
+
+	if s.BaseURL != "" {
+		return validatePageURL(s.BaseURL, "source.base_url", true)
+	}
+	return nil
+}
+
+func (s SourceConfig) validateWARC() error {
+	if s.Path == "" {
+		return fmt.Errorf("source.path is required for the warc source")
+	}
+	info, err := os.Stat(s.Path)
+	if err != nil {
+		return fmt.Errorf("invalid source.path: %w", err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("source.path must be a file: '%s'", s.Path)
+	}
+	return nil
+}
+
+// SitemapConfig controls seeding the crawl from sitemap.xml files.
+type SitemapConfig struct {
+	// URLs lists sitemap or sitemap index files to read, plain or gzipped.
+	URLs []string `yaml:"urls"`
+	// Discover reads additional sitemaps from robots.txt "Sitemap:" lines.
+	Discover bool `yaml:"discover"`
+	// Only disables link following so that only start URLs and sitemap
+	// entries are scraped.
+	Only bool `yaml:"only"`
+}
+
+// Enabled reports whether any sitemap source is configured.
+func (s SitemapConfig) Enabled() bool {
+	return len(s.URLs) > 0 || s.Discover
+}
+
+// SkipCategory is a named group of URL path segments that are usually not
+// documentation, such as legal or login pages. Each pattern is a regular
+// expression matched case-insensitively against a whole path segment.
+type SkipCategory struct {
+	Name     string
+	Patterns []string
+	// Default categories apply unless listed in skip_categories.disabled.
+	Default bool
+}
+
+// BuiltinSkipCategories are the categories known without configuration.
+var BuiltinSkipCategories = []SkipCategory{
+	{Name: "legal", Patterns: []string{"privacy", "terms", "legal", "cookies", "gdpr"}, Default: true},
+	{Name: "auth", Patterns: []string{"login", "signup", "register", "account", "profile"}, Default: true},
+	{Name: "company", Patterns: []string{"about", "contact", "careers", "jobs"}, Default: true},
+	{Name: "marketing", Patterns: []string{"pricing", "enterprise", "commercial", "sales"}, Default: true},
+	// Only the blog index is skipped; individual posts may be worth keeping
+	{Name: "news", Patterns: []string{"blog(?:[?#].*)?$", "news", "press", "media"}, Default: true},
+	{Name: "support", Patterns: []string{"support"}},
+	{Name: "site_status", Patterns: []string{"404", "error", "maintenance", "status"}},
+}
+
+// SkipCategoriesConfig selects which skip categories apply.
+type SkipCategoriesConfig struct {
+	// Enabled turns on built-in categories that are off by default.
+	Enabled []string `yaml:"enabled"`
+	// Disabled turns off built-in categories that are on by default.
+	Disabled []string `yaml:"disabled"`
+	// Custom defines additional categories, which always apply.
+	Custom map[string][]string `yaml:"custom"`
+}
+
+// Active returns the categories to apply: built-in ones in definition
+// order, then custom ones sorted by name.
+func (c SkipCategoriesConfig) Active() []SkipCategory {
+	enabled := make(map[string]bool)
+	for _, name := range c.Enabled {
+		enabled[name] = true
+	}
+	disabled := make(map[string]bool)
+	for _, name := range c.Disabled {
+		disabled[name] = true
+	}
+
+	var active []SkipCategory
+	for _, category := range BuiltinSkipCategories {
+		if (category.Default || enabled[category.Name]) && !disabled[category.Name] {
+			active = append(active, category)
+		}
+	}
+
+	names := make([]string, 0, len(c.Custom))
+	for name := range c.Custom {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		active = append(active, SkipCategory{Name: name, Patterns: c.Custom[name]})
+	}
+
+	return active
+}
+
+// SkipCategoryPattern returns the regular expression matching a URL whose
+// path contains a segment matching one of patterns.
+func SkipCategoryPattern(patterns []string) string {
+	return `(?i)/(` + strings.Join(patterns, "|") + `)($|[/?#])`
+}
+
+func (c SkipCategoriesConfig) validate() error {
+	builtin := make(map[string]bool)
+	for _, category := range BuiltinSkipCategories {
+		builtin[category.Name] = true
+	}
+
+	for field, names := range map[string][]string{"enabled": c.Enabled, "disabled": c.Disabled} {
+		for i, name := range names {
+			if !builtin[name] {
+				return fmt.Errorf("unknown skip_categories.%s[%d] '%s'", field, i, name)
+			}
+		}
+	}
+
+	for name, patterns := range c.Custom {
+		if name == "" {
+			return fmt.Errorf("skip_categories.custom names cannot be empty")
+		}
+		if builtin[name] {
+			return fmt.Errorf("skip_categories.custom.%s clashes with a built-in category", name)
+		}
+		if len(patterns) == 0 {
+			return fmt.Errorf("skip_categories.custom.%s must list at least one pattern", name)
+		}
+		for i, pattern := range patterns {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("invalid skip_categories.custom.%s[%d] '%s': %w", name, i, pattern, err)
+			}
+		}
+		if _, err := regexp.Compile(SkipCategoryPattern(patterns)); err != nil {
+			return fmt.Errorf("invalid skip_categories.custom.%s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// Trailing slash handling for canonical URLs.
+const (
+	TrailingSlashStrip = "strip"
+	TrailingSlashAdd   = "add"
+	TrailingSlashKeep  = "keep"
+)
+
+// DefaultDropQueryParams are tracking parameters removed from URLs unless
+// canonicalize.drop_query_params is set.
+var DefaultDropQueryParams = []string{"utm_*", "fbclid", "gclid", "msclkid"}
+
+// CanonicalConfig controls how URLs are normalized before they are
+// deduplicated and visited. Host names are always lowercased.
+type CanonicalConfig struct {
+	// DropQueryParams lists query parameters to remove. A trailing "*"
+	// matches every parameter with that prefix.
+	DropQueryParams []string `yaml:"drop_query_params"`
+	// TrailingSlash is "strip" (default), "add" or "keep".
+	TrailingSlash string `yaml:"trailing_slash"`
+	// KeepFragments keeps "#fragment" parts instead of stripping them.
+	KeepFragments bool `yaml:"keep_fragments"`
+	// KeepIndexFiles keeps ".../index.html" instead of resolving it to
+	// its directory.
+	KeepIndexFiles bool `yaml:"keep_index_files"`
+	// IgnoreRelCanonical ignores <link rel="canonical"> declared by pages.
+	IgnoreRelCanonical bool `yaml:"ignore_rel_canonical"`
+}
+
+// ThrottleConfig controls how the crawl slows down for a host that answers
+// 429 Too Many Requests or 503 Service Unavailable.
+type ThrottleConfig struct {
+	// MaxDelay caps the extra delay added between requests to a throttled host.
+	MaxDelay time.Duration `yaml:"max_delay"`
+	// MaxRequeues is how often a throttled URL is re-queued before giving up.
+	MaxRequeues int `yaml:"max_requeues"`
+}
+
+// Defaults of throttle.max_delay and throttle.max_requeues.
+const (
+	DefaultThrottleMaxDelay    = 2 * time.Minute
+	DefaultThrottleMaxRequeues = 5
+)
+
+// Network error kinds accepted by retry.network_errors.
+const (
+	NetworkErrorTimeout           = "timeout"
+	NetworkErrorConnectionRefused = "connection_refused"
+	NetworkErrorConnectionReset   = "connection_reset"
+	NetworkErrorEOF               = "eof"
+	NetworkErrorDNS               = "dns"
+)
+
+// RetryNetworkErrorKinds lists every network error kind; all of them are
+// retried by default.
+var RetryNetworkErrorKinds = []string{
+	NetworkErrorTimeout,
+	NetworkErrorConnectionRefused,
+	NetworkErrorConnectionReset,
+	NetworkErrorEOF,
+	NetworkErrorDNS,
+}
+
+// DefaultRetryStatusCodes are retried unless retry.status_codes is set.
+// 429 and 503 are handled by throttling instead.
+var DefaultRetryStatusCodes = []int{408, 500, 502, 504}
+
+// Defaults of retry.max_attempts, retry.backoff_base and retry.backoff_max.
+const (
+	DefaultRetryMaxAttempts = 3
+	DefaultRetryBackoffBase = 1 * time.Second
+	DefaultRetryBackoffMax  = 30 * time.Second
+)
+
+// RetryConfig controls how failed requests are retried.
+type RetryConfig struct {
+	// MaxAttempts is the total number of attempts per URL, including the first.
+	MaxAttempts int `yaml:"max_attempts"`
+	// BackoffBase is the wait before the first retry; it doubles on every
+	// further attempt up to BackoffMax.
+	BackoffBase time.Duration `yaml:"backoff_base"`
+	BackoffMax  time.Duration `yaml:"backoff_max"`
+	// StatusCodes lists the HTTP status codes that are retried.
+	StatusCodes []int `yaml:"status_codes"`
+	// NetworkErrors lists the network error kinds that are retried.
+	NetworkErrors []string `yaml:"network_errors"`
+}
+
+// RequestsConfig customizes outgoing requests per domain, for crawling
+// documentation behind a login. Secrets are read from environment
+// variables or files so they never need to be stored in the YAML.
+type RequestsConfig struct {
+	// CookiesFile is a Netscape cookies.txt export, as written by browser
+	// extensions and curl.
+	CookiesFile string `yaml:"cookies_file"`
+	// Domains lists per-domain settings; the first entry matching a
+	// request's host applies.
+	Domains []DomainRequestConfig `yaml:"domains"`
+}
+
+// DomainRequestConfig holds the headers and credentials sent to a domain.
+type DomainRequestConfig struct {
+	// Domain is a host name, or "*.example.com" for example.com and all
+	// of its subdomains.
+	Domain      string            `yaml:"domain"`
+	Headers     map[string]string `yaml:"headers"`
+	BearerToken *SecretRef        `yaml:"bearer_token"`
+	BasicAuth   *BasicAuthConfig  `yaml:"basic_auth"`
+}
+
+// BasicAuthConfig is a user name with a password read from a secret.
+type BasicAuthConfig struct {
+	Username string    `yaml:"username"`
+	Password SecretRef `yaml:"password"`
+}
+
+// SecretRef names where a secret is read from: an environment variable or
+// a file, whose surrounding whitespace is trimmed.
+type SecretRef struct {
+	Env  string `yaml:"env"`
+	File string `yaml:"file"`
+}
+
+// credentialHeaders may only be set through bearer_token and basic_auth.
+var credentialHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+// Matches reports whether the settings apply to host.
+func (d DomainRequestConfig) Matches(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	domain := strings.ToLower(d.Domain)
+	if base, ok := strings.CutPrefix(domain, "*."); ok {
+		return host == base || strings.HasSuffix(host, "."+base)
+	}
+	return host == domain
+}
+
+// Resolve reads the secret. Errors name the variable or file, never the value.
+func (r SecretRef) Resolve() (string, error) {
+	var value string
+	switch {
+	case r.Env != "":
+		value = os.Getenv(r.Env)
+		if value == "" {
+			return "", fmt.Errorf("environment variable %s is not set", r.Env)
+		}
+	case r.File != "":
+		data, err := os.ReadFile(r.File)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret file: %w", err)
+		}
+		value = strings.TrimSpace(string(data))
+		if value == "" {
+			return "", fmt.Errorf("secret file %s is empty", r.File)
+		}
+	}
+	return value, nil
+}
+
+func (r SecretRef) validate(field string) error {
+	if (r.Env == "") == (r.File == "") {
+		return fmt.Errorf("%s must set exactly one of env or file", field)
+	}
+	return nil
+}
+
+func (c RequestsConfig) validate() error {
+	if c.CookiesFile != "" {
+		if _, err := os.Stat(c.CookiesFile); err != nil {
+			return fmt.Errorf("invalid requests.cookies_file: %w", err)
+		}
+	}
+
+	for i, d := range c.Domains {
+		field := fmt.Sprintf("requests.domains[%d]", i)
+		domain := strings.TrimPrefix(d.Domain, "*.")
+		if domain == "" || strings.ContainsAny(domain, "/:*@ ") {
+			return fmt.Errorf("invalid %s.domain '%s': must be a host name or *.domain", field, d.Domain)
+		}
+		for name := range d.Headers {
+			for _, credential := range credentialHeaders {
+				if strings.EqualFold(name, credential) {
+					return fmt.Errorf("%s.headers cannot set %s; use bearer_token, basic_auth or cookies_file", field, credential)
+				}
+			}
+		}
+		if d.BearerToken != nil && d.BasicAuth != nil {
+			return fmt.Errorf("%s cannot set both bearer_token and basic_auth", field)
+		}
+		if d.BearerToken != nil {
+			if err := d.BearerToken.validate(field + ".bearer_token"); err != nil {
+				return err
+			}
+		}
+		if d.BasicAuth != nil {
+			if d.BasicAuth.Username == "" {
+				return fmt.Errorf("%s.basic_auth.username is required", field)
+			}
+			if err := d.BasicAuth.Password.validate(field + ".basic_auth.password"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// TLSVersions maps network.tls_min_version values to TLS versions.
+var TLSVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// NetworkConfig controls how the crawler connects, for networks with
+// proxies and private certificate authorities. It applies to every fetch,
+// including robots.txt and sitemaps.
+type NetworkConfig struct {
+	Proxy ProxyConfig `yaml:"proxy"`
+	// CAFiles lists PEM files of root CAs trusted in addition to the
+	// system ones.
+	CAFiles []string `yaml:"ca_files"`
+	// ClientCert and ClientKey are PEM files of a client certificate
+	// presented for mutual TLS.
+	ClientCert string `yaml:"client_cert"`
+	ClientKey  string `yaml:"client_key"`
+	// TLSMinVersion is the lowest accepted TLS version: "1.0" to "1.3".
+	TLSMinVersion string `yaml:"tls_min_version"`
+}
+
+// ProxyConfig selects the proxy for outgoing requests. When no proxy is
+// set, the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables are
+// used as before.
+type ProxyConfig struct {
+	// URL is the proxy for both schemes; HTTP and HTTPS override it.
+	URL   string `yaml:"url"`
+	HTTP  string `yaml:"http"`
+	HTTPS string `yaml:"https"`
+	// NoProxy lists hosts, domains (".example.com"), IPs and CIDR ranges
+	// reached directly, as in NO_PROXY.
+	NoProxy []string `yaml:"no_proxy"`
+	// Username and Password authenticate with the proxy.
+	Username string     `yaml:"username"`
+	Password *SecretRef `yaml:"password"`
+}
+
+// IsSet reports whether a proxy is configured.
+func (p ProxyConfig) IsSet() bool {
+	return p.URL != "" || p.HTTP != "" || p.HTTPS != ""
+}
+
+// ForScheme returns the proxy URL for requests with the given scheme.
+func (p ProxyConfig) ForScheme(scheme string) string {
+	switch {
+	case scheme == "http" && p.HTTP != "":
+		return p.HTTP
+	case scheme == "https" && p.HTTPS != "":
+		return p.HTTPS
+	}
+	return p.URL
+}
+
+func (c NetworkConfig) validate() error {
+	proxies := map[string]string{"url": c.Proxy.URL, "http": c.Proxy.HTTP, "https": c.Proxy.HTTPS}
+	for _, field := range []string{"url", "http", "https"} {
+		raw := proxies[field]
+		if raw == "" {
+			continue
+		}
+		u, err := url.Parse(raw)
+		if err != nil {
+			return fmt.Errorf("invalid network.proxy.%s: %w", field, err)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return fmt.Errorf("network.proxy.%s must use http, https or socks5, got '%s'", field, u.Scheme)
+		}
+		if u.Host == "" {
+			return fmt.Errorf("network.proxy.%s must include a host", field)
+		}
+		if _, ok := u.User.Password(); ok {
+			return fmt.Errorf("network.proxy.%s cannot contain a password; use network.proxy.password", field)
+		}
+	}
+	if !c.Proxy.IsSet() && (len(c.Proxy.NoProxy) > 0 || c.Proxy.Username != "" || c.Proxy.Password != nil) {
+		return fmt.Errorf("network.proxy.no_proxy, username and password require a proxy URL")
+	}
+	if c.Proxy.Password != nil {
+		if c.Proxy.Username == "" {
+			return fmt.Errorf("network.proxy.username is required with network.proxy.password")
+		}
+		if err := c.Proxy.Password.validate("network.proxy.password"); err != nil {
+			return err
+		}
+	}
+
+	for i, file := range c.CAFiles {
+		if _, err := os.Stat(file); err != nil {
+			return fmt.Errorf("invalid network.ca_files[%d]: %w", i, err)
+		}
+	}
+	if (c.ClientCert == "") != (c.ClientKey == "") {
+		return fmt.Errorf("network.client_cert and network.client_key must be set together")
+	}
+	if c.TLSMinVersion != "" {
+		if _, ok := TLSVersions[c.TLSMinVersion]; !ok {
+			return fmt.Errorf("network.tls_min_version must be one of 1.0, 1.1, 1.2 or 1.3, got '%s'", c.TLSMinVersion)
+		}
+	}
+	return nil
+}
+
 type ProcessingConfig struct {
 	MaxDepth           int     `yaml:"max_depth"`
 	Concurrency        int     `yaml:"concurrency"`
+	Workers            int     `yaml:"workers"`
 	Delay              float64 `yaml:"delay"`
 	PreserveCodeBlocks bool    `yaml:"preserve_code_blocks"`
 	GenerateTOC        bool    `yaml:"generate_toc"`
 	SanitizeHTML       bool    `yaml:"sanitize_html"`
+	MaxPages           int     `yaml:"max_pages"`
 }
 
 type EngineConfig struct {
@@ -51,12 +715,60 @@ type EngineConfig struct {
 	WaitSelector string `yaml:"wait_selector"`
 }
 
+// How preserved images are written to the output.
+const (
+	ImagesRemote   = "remote"
+	ImagesDownload = "download"
+	ImagesDataURI  = "data_uri"
+	ImagesAltText  = "alt_text"
+)
+
+// GitHub alert types that callouts are converted to.
+var AlertTypes = []string{"note", "tip", "important", "warning", "caution"}
+
+// DefaultAssetsDir is the directory next to the output file that
+// downloaded images are saved to.
+const DefaultAssetsDir = "assets"
+
+// DefaultHeadingOffset places the top headings of a page one level below
+// its title.
+const DefaultHeadingOffset = 1
+
 type OutputConfig struct {
-	HeadingOffset      int  `yaml:"heading_offset"`
-	IncludeMetadata    bool `yaml:"include_metadata"`
-	SyntaxHighlighting bool `yaml:"syntax_highlighting"`
-	PreserveImages     bool `yaml:"preserve_images"`
-	InlineStyles       bool `yaml:"inline_styles"`
+	// HeadingOffset is how many levels below its page title the top
+	// headings of a page are placed; 0 puts them level with the title.
+	// Unset means DefaultHeadingOffset.
+	HeadingOffset      *int   `yaml:"heading_offset"`
+	IncludeMetadata    bool   `yaml:"include_metadata"`
+	SyntaxHighlighting bool   `yaml:"syntax_highlighting"`
+	PreserveImages     bool   `yaml:"preserve_images"`
+	// Images is how preserved images are written: "remote" (default)
+	// links their original URL, "download" saves them to assets/ next to
+	// the output file, "data_uri" inlines them and "alt_text" replaces
+	// them with their alt text. Setting it turns preserve_images on.
+	Images string `yaml:"images"`
+	InlineStyles       bool   `yaml:"inline_styles"`
+	MaxOutputSize      string `yaml:"max_output_size"`
+	MaxOutputSizeBytes int64
+	// WritePartial writes the pages scraped so far when the crawl times
+	// out or is cancelled
+	WritePartial bool `yaml:"write_partial"`
+	// AlertClasses maps CSS classes of callouts the converter does not
+	// recognize to a GitHub alert type, one of AlertTypes
+	AlertClasses map[string]string `yaml:"alert_classes"`
+	// PreferredTabs keeps only the panels of tabbed examples labeled with
+	// one of these names, such as "curl" or "Go"
+	PreferredTabs []string `yaml:"preferred_tabs"`
+}
+
+// HeadingLevels returns how many levels below its page title the top
+// headings of a page go: heading_offset, or DefaultHeadingOffset when it
+// is not set.
+func (o OutputConfig) HeadingLevels() int {
+	if o.HeadingOffset == nil {
+		return DefaultHeadingOffset
+	}
+	return *o.HeadingOffset
 }
 
 type SecurityConfig struct {
@@ -76,6 +788,19 @@ type MonitoringConfig struct {
 	MetricsPort       int    `yaml:"metrics_port"`
 }
 
+// StateConfig controls on-disk checkpoints used to resume interrupted crawls.
+type StateConfig struct {
+	Dir                string        `yaml:"dir"`
+	Resume             bool          `yaml:"resume"`
+	CheckpointInterval time.Duration `yaml:"checkpoint_interval"`
+}
+
+// CacheConfig enables incremental re-scrapes. Pages are cached per URL in
+// Dir and revalidated with conditional requests on later runs.
+type CacheConfig struct {
+	Dir string `yaml:"dir"`
+}
+
 func LoadConfig(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -105,9 +830,21 @@ func (c *Config) SetDefaults() error {
 	if c.Processing.Concurrency == 0 {
 		c.Processing.Concurrency = 3
 	}
+	if c.Processing.Workers == 0 {
+		c.Processing.Workers = runtime.NumCPU()
+	}
 	if c.Processing.Delay == 0 {
 		c.Processing.Delay = 1.0
 	}
+	if c.Output.HeadingOffset == nil {
+		offset := DefaultHeadingOffset
+		c.Output.HeadingOffset = &offset
+	}
+	if c.Output.Images != "" {
+		c.Output.PreserveImages = true
+	} else if c.Output.PreserveImages {
+		c.Output.Images = ImagesRemote
+	}
 	if c.Selectors.Title == "" {
 		c.Selectors.Title = "h1"
 	}
@@ -129,6 +866,14 @@ func (c *Config) SetDefaults() error {
 		return fmt.Errorf("invalid max_file_size: %w", err)
 	}
 	c.Security.MaxFileSizeBytes = maxSize
+
+	if c.Output.MaxOutputSize != "" {
+		maxOutput, err := parseSize(c.Output.MaxOutputSize)
+		if err != nil {
+			return fmt.Errorf("invalid max_output_size: %w", err)
+		}
+		c.Output.MaxOutputSizeBytes = maxOutput
+	}
 	c.Security.RequestTimeout = 30 * time.Second
 	
 	// Set default scraping timeout - generous for large documentation sites
@@ -136,6 +881,67 @@ func (c *Config) SetDefaults() error {
 		c.Security.ScrapingTimeout = 10 * time.Minute
 	}
 
+	if c.Source.IsFilesystem() {
+		if c.Source.Root != "" {
+			root, err := filepath.Abs(c.Source.Root)
+			if err != nil {
+				return fmt.Errorf("invalid source.root: %w", err)
+			}
+			c.Source.Root = root
+		}
+		if c.Source.BaseURL == "" {
+			c.Source.BaseURL = DefaultFilesystemBaseURL
+		}
+		if !strings.HasSuffix(c.Source.BaseURL, "/") {
+			c.Source.BaseURL += "/"
+		}
+		if c.BaseURL == "" {
+			c.BaseURL = c.Source.BaseURL
+		}
+		// Start from the root index; unlinked files are visited afterwards
+		if len(c.StartURLs) == 0 {
+			c.StartURLs = []string{c.Source.BaseURL}
+		}
+	}
+
+	if c.Canonicalize.DropQueryParams == nil {
+		c.Canonicalize.DropQueryParams = append([]string(nil), DefaultDropQueryParams...)
+	}
+	if c.Canonicalize.TrailingSlash == "" {
+		c.Canonicalize.TrailingSlash = TrailingSlashStrip
+	}
+
+	if c.TitleRules.Fallback == nil {
+		c.TitleRules.Fallback = append([]string(nil), DefaultTitleFallback...)
+	}
+
+	if c.Throttle.MaxDelay == 0 {
+		c.Throttle.MaxDelay = DefaultThrottleMaxDelay
+	}
+	if c.Throttle.MaxRequeues == 0 {
+		c.Throttle.MaxRequeues = DefaultThrottleMaxRequeues
+	}
+
+	if c.Retry.MaxAttempts == 0 {
+		c.Retry.MaxAttempts = DefaultRetryMaxAttempts
+	}
+	if c.Retry.BackoffBase == 0 {
+		c.Retry.BackoffBase = DefaultRetryBackoffBase
+	}
+	if c.Retry.BackoffMax == 0 {
+		c.Retry.BackoffMax = DefaultRetryBackoffMax
+	}
+	if c.Retry.StatusCodes == nil {
+		c.Retry.StatusCodes = append([]int(nil), DefaultRetryStatusCodes...)
+	}
+	if c.Retry.NetworkErrors == nil {
+		c.Retry.NetworkErrors = append([]string(nil), RetryNetworkErrorKinds...)
+	}
+
+	if c.State.CheckpointInterval == 0 {
+		c.State.CheckpointInterval = 30 * time.Second
+	}
+
 	if len(c.Engines) == 0 {
 		c.Engines = []EngineConfig{
 			{
@@ -154,25 +960,46 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("name is required")
 	}
 	
-	if err := validateURL(c.BaseURL, "base_url"); err != nil {
+	if err := c.Source.validate(); err != nil {
+		return err
+	}
+
+	if err := validatePageURL(c.BaseURL, "base_url", c.Source.IsFilesystem()); err != nil {
 		return err
 	}
+
+	if c.Source.IsWARC() && c.WARCFile != "" && filepath.Clean(c.WARCFile) == filepath.Clean(c.Source.Path) {
+		return fmt.Errorf("warc_file cannot overwrite the replayed source.path '%s'", c.Source.Path)
+	}
 	
 	if c.OutputFile == "" {
 		return fmt.Errorf("output_file is required")
 	}
 	
-	if len(c.StartURLs) == 0 {
+	if len(c.StartURLs) == 0 && !c.Sitemaps.Enabled() {
 		return fmt.Errorf("start_urls is required and must contain at least one URL")
 	}
 
 	// Validate all start URLs
 	for i, startURL := range c.StartURLs {
-		if err := validateURL(startURL, fmt.Sprintf("start_urls[%d]", i)); err != nil {
+		if err := validatePageURL(startURL, fmt.Sprintf("start_urls[%d]", i), c.Source.IsFilesystem()); err != nil {
 			return err
 		}
 	}
 
+	for i, sitemapURL := range c.Sitemaps.URLs {
+		if err := validateURL(sitemapURL, fmt.Sprintf("sitemaps.urls[%d]", i)); err != nil {
+			return err
+		}
+	}
+
+	switch c.Canonicalize.TrailingSlash {
+	case "", TrailingSlashStrip, TrailingSlashAdd, TrailingSlashKeep:
+	default:
+		return fmt.Errorf("canonicalize.trailing_slash must be %q, %q or %q, got %q",
+			TrailingSlashStrip, TrailingSlashAdd, TrailingSlashKeep, c.Canonicalize.TrailingSlash)
+	}
+
 	// Validate regex patterns
 	for i, pattern := range c.FollowPatterns {
 		if _, err := regexp.Compile(pattern); err != nil {
@@ -186,6 +1013,14 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	if err := c.SkipCategories.validate(); err != nil {
+		return err
+	}
+
+	if err := c.TitleRules.validate(); err != nil {
+		return err
+	}
+
 	// Validate processing configuration
 	if c.Processing.MaxDepth <= 0 {
 		return fmt.Errorf("max_depth must be greater than 0, got %d", c.Processing.MaxDepth)
@@ -193,9 +1028,59 @@ func (c *Config) Validate() error {
 	if c.Processing.Concurrency <= 0 {
 		return fmt.Errorf("concurrency must be greater than 0, got %d", c.Processing.Concurrency)
 	}
+	if c.Processing.Workers < 0 {
+		return fmt.Errorf("workers must be non-negative, got %d", c.Processing.Workers)
+	}
 	if c.Processing.Delay < 0 {
 		return fmt.Errorf("delay must be non-negative, got %f", c.Processing.Delay)
 	}
+	if c.Processing.MaxPages < 0 {
+		return fmt.Errorf("max_pages must be non-negative, got %d", c.Processing.MaxPages)
+	}
+	if c.Output.HeadingOffset != nil && *c.Output.HeadingOffset < 0 {
+		return fmt.Errorf("heading_offset must be non-negative, got %d", *c.Output.HeadingOffset)
+	}
+	for class, alert := range c.Output.AlertClasses {
+		if class == "" {
+			return fmt.Errorf("output.alert_classes cannot have an empty class")
+		}
+		if !isAlertType(alert) {
+			return fmt.Errorf("output.alert_classes.%s must be one of %s, got %q",
+				class, strings.Join(AlertTypes, ", "), alert)
+		}
+	}
+	switch c.Output.Images {
+	case "", ImagesRemote, ImagesDownload, ImagesDataURI, ImagesAltText:
+	default:
+		return fmt.Errorf("output.images must be %q, %q, %q or %q, got %q",
+			ImagesRemote, ImagesDownload, ImagesDataURI, ImagesAltText, c.Output.Images)
+	}
+
+	if c.Throttle.MaxDelay < 0 {
+		return fmt.Errorf("throttle.max_delay must be non-negative, got %s", c.Throttle.MaxDelay)
+	}
+	if c.Throttle.MaxRequeues < 0 {
+		return fmt.Errorf("throttle.max_requeues must be non-negative, got %d", c.Throttle.MaxRequeues)
+	}
+
+	if err := c.Retry.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Requests.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Network.validate(); err != nil {
+		return err
+	}
+
+	if c.State.Resume && c.State.Dir == "" {
+		return fmt.Errorf("state.dir is required to resume a crawl")
+	}
+	if c.State.CheckpointInterval < 0 {
+		return fmt.Errorf("checkpoint_interval must be non-negative, got %s", c.State.CheckpointInterval)
+	}
 
 	// Validate allowed domains if specified
 	for i, domain := range c.Security.AllowedDomains {
@@ -211,6 +1096,50 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+func (r RetryConfig) validate() error {
+	if r.MaxAttempts < 0 {
+		return fmt.Errorf("retry.max_attempts must be non-negative, got %d", r.MaxAttempts)
+	}
+	if r.BackoffBase < 0 || r.BackoffMax < 0 {
+		return fmt.Errorf("retry backoff must be non-negative")
+	}
+	if r.BackoffMax > 0 && r.BackoffBase > r.BackoffMax {
+		return fmt.Errorf("retry.backoff_base (%s) must not exceed retry.backoff_max (%s)", r.BackoffBase, r.BackoffMax)
+	}
+	for i, code := range r.StatusCodes {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("invalid retry.status_codes[%d]: %d", i, code)
+		}
+	}
+	for i, kind := range r.NetworkErrors {
+		known := false
+		for _, k := range RetryNetworkErrorKinds {
+			if kind == k {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("invalid retry.network_errors[%d] '%s': must be one of %v", i, kind, RetryNetworkErrorKinds)
+		}
+	}
+	return nil
+}
+
+// validatePageURL validates a page URL, which may also be a file:// URL
+// when allowFile is set.
+func validatePageURL(urlStr, fieldName string, allowFile bool) error {
+	if allowFile {
+		if parsed, err := url.Parse(urlStr); err == nil && parsed.Scheme == "file" {
+			if parsed.Path == "" {
+				return fmt.Errorf("%s must include a path: '%s'", fieldName, urlStr)
+			}
+			return nil
+		}
+	}
+	return validateURL(urlStr, fieldName)
+}
+
 // validateURL validates that a URL string is well-formed and contains required components
 func validateURL(urlStr, fieldName string) error {
 	if urlStr == "" {
@@ -266,4 +1195,14 @@ func parseSize(sizeStr string) (int64, error) {
 	}
 
 	return num * multiplier, nil
-}
\ No newline at end of file
+}
+
+// isAlertType reports whether name is one of AlertTypes, ignoring case.
+func isAlertType(name string) bool {
+	for _, alert := range AlertTypes {
+		if strings.EqualFold(name, alert) {
+			return true
+		}
+	}
+	return false
+}
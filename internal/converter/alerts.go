@@ -0,0 +1,276 @@
+package converter
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// calloutClasses mark an element as a callout: Docusaurus, MkDocs and
+// Sphinx admonitions, Nextra and Mintlify callouts, VitePress custom
+// blocks, GitBook hints and Bootstrap alerts.
+var calloutClasses = []string{
+	"admonition", "theme-admonition", "nextra-callout", "callout", "custom-block", "hint", "alert",
+}
+
+// calloutTitleSelector matches the title of a callout.
+const calloutTitleSelector = ".admonition-title, .admonition-heading, [class*=admonitionHeading], " +
+	".custom-block-title, .callout-title, .hint-title, .alert-heading, summary"
+
+// calloutIconSelector matches the icon of a callout.
+const calloutIconSelector = ".admonition-icon, [class*=admonitionIcon], .callout-icon, svg"
+
+// calloutBodySelector matches the element wrapping the body of a callout.
+const calloutBodySelector = ".admonition-content, [class*=admonitionContent], .callout-content"
+
+// alertKinds maps the type names used in callout classes to GitHub alert
+// types.
+var alertKinds = map[string]string{
+	"note":      "NOTE",
+	"info":      "NOTE",
+	"seealso":   "NOTE",
+	"abstract":  "NOTE",
+	"summary":   "NOTE",
+	"example":   "NOTE",
+	"question":  "NOTE",
+	"secondary": "NOTE",
+	"primary":   "NOTE",
+	"tip":       "TIP",
+	"hint":      "TIP",
+	"success":   "TIP",
+	"important": "IMPORTANT",
+	"attention": "IMPORTANT",
+	"warning":   "WARNING",
+	"warn":      "WARNING",
+	"caution":   "CAUTION",
+	"danger":    "CAUTION",
+	"error":     "CAUTION",
+	"failure":   "CAUTION",
+	"bug":       "CAUTION",
+}
+
+// sphinxAdmonitions are the classes Sphinx gives admonitions without an
+// admonition class, as in <div class="note">.
+var sphinxAdmonitions = map[string]bool{
+	"note": true, "seealso": true, "tip": true, "hint": true, "important": true,
+	"attention": true, "warning": true, "caution": true, "danger": true, "error": true,
+}
+
+// calloutIcons map the emoji Nextra puts before a callout's text.
+var calloutIcons = map[string]string{
+	"💡":  "TIP",
+	"⚠️": "WARNING",
+	"⚠":  "WARNING",
+	"🚫":  "CAUTION",
+	"❗":  "IMPORTANT",
+	"ℹ️": "NOTE",
+}
+
+// alertMarkerPrefix starts the paragraph that stands in for the alert
+// marker until the markdown is written, since a literal [!NOTE] would be
+// escaped.
+const alertMarkerPrefix = "markdocify-alert-"
+
+// alertMarkerRe matches the marker line inside the converted blockquote.
+var alertMarkerRe = regexp.MustCompile(`^((?:> ?)+)` + alertMarkerPrefix + `([A-Z]+)$`)
+
+// convertCallouts rewrites the callouts of a page as blockquotes that
+// become GitHub alerts, keeping their title. It runs before sanitizing,
+// which strips the classes callouts are recognized by.
+func (c *Converter) convertCallouts(content string) string {
+	if !strings.Contains(content, "class=") && !strings.Contains(content, "data-callout") {
+		return content
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return content
+	}
+
+	var callouts []*goquery.Selection
+	doc.Find("div, aside, details, section, blockquote").Each(func(_ int, el *goquery.Selection) {
+		if c.alertType(el) != "" {
+			callouts = append(callouts, el)
+		}
+	})
+	if len(callouts) == 0 {
+		return content
+	}
+
+	// Innermost first, so an outer callout keeps the converted inner one
+	for i := len(callouts) - 1; i >= 0; i-- {
+		c.replaceCallout(callouts[i])
+	}
+
+	body, err := doc.Find("body").Html()
+	if err != nil {
+		return content
+	}
+	return body
+}
+
+// alertType returns the GitHub alert type of a callout element, or "" if
+// el is not a callout. Classes mapped in output.alert_classes come first.
+func (c *Converter) alertType(el *goquery.Selection) string {
+	classes := strings.Fields(el.AttrOr("class", ""))
+	for _, class := range classes {
+		if alert, ok := c.config.Output.AlertClasses[class]; ok {
+			return strings.ToUpper(alert)
+		}
+	}
+
+	// Mintlify
+	for _, attr := range []string{"data-callout-type", "data-callout"} {
+		if value, ok := el.Attr(attr); ok {
+			if alert, ok := alertKinds[strings.ToLower(value)]; ok {
+				return alert
+			}
+			return "NOTE"
+		}
+	}
+
+	callout := false
+	kind := ""
+	for _, class := range classes {
+		class = strings.ToLower(class)
+		if isCalloutClass(class) {
+			callout = true
+		}
+		if kind != "" {
+			continue
+		}
+		if alert, ok := alertKinds[class]; ok {
+			kind = alert
+			callout = callout || (sphinxAdmonitions[class] && len(classes) == 1 && goquery.NodeName(el) != "section")
+		} else if alert, ok := alertKinds[calloutSuffix(class)]; ok {
+			kind = alert
+			callout = true
+		}
+	}
+
+	switch {
+	case !callout:
+		return ""
+	case kind != "":
+		return kind
+	}
+	if alert := iconAlert(el); alert != "" {
+		return alert
+	}
+	return "NOTE"
+}
+
+func isCalloutClass(class string) bool {
+	for _, marker := range calloutClasses {
+		if class == marker {
+			return true
+		}
+	}
+	return false
+}
+
+// calloutSuffix returns the type named by a class such as admonition-note,
+// theme-admonition-warning, alert--info or hint-success, or "".
+func calloutSuffix(class string) string {
+	for _, marker := range calloutClasses {
+		rest := strings.TrimPrefix(class, marker)
+		if rest != class && (strings.HasPrefix(rest, "-") || strings.HasPrefix(rest, "_")) {
+			return strings.TrimLeft(rest, "-_")
+		}
+	}
+	return ""
+}
+
+// iconAlert reads the alert type from the emoji a callout starts with.
+func iconAlert(el *goquery.Selection) string {
+	text := strings.TrimSpace(el.Text())
+	for icon, alert := range calloutIcons {
+		if strings.HasPrefix(text, icon) {
+			return alert
+		}
+	}
+	return ""
+}
+
+// replaceCallout replaces a callout with a blockquote holding the alert
+// marker, the callout's title unless it only names the alert type, and
+// the callout's body.
+func (c *Converter) replaceCallout(el *goquery.Selection) {
+	alert := c.alertType(el)
+
+	title := ""
+	if heading := el.Find(calloutTitleSelector).First(); heading.Length() > 0 {
+		heading.Find(calloutIconSelector).Remove()
+		title = strings.Join(strings.Fields(heading.Text()), " ")
+		heading.Remove()
+	}
+	el.Find(calloutIconSelector).Remove()
+	// Nextra puts its emoji in a div of its own
+	if first := el.Children().First(); first.Length() > 0 && isIcon(first.Text()) && el.Children().Length() > 1 {
+		first.Remove()
+	}
+
+	body := el
+	if wrapper := el.Find(calloutBodySelector).First(); wrapper.Length() > 0 {
+		body = wrapper
+	}
+	inner, err := body.Html()
+	if err != nil {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("<blockquote><p>" + alertMarkerPrefix + alert + "</p>")
+	if title != "" && alertKinds[strings.ToLower(title)] != alert {
+		b.WriteString("<p><strong>" + html.EscapeString(title) + "</strong></p>")
+	}
+	b.WriteString(inner)
+	b.WriteString("</blockquote>")
+	el.ReplaceWithHtml(b.String())
+}
+
+// isIcon reports whether text is nothing but an emoji or symbol.
+func isIcon(text string) bool {
+	text = strings.TrimSpace(text)
+	if text == "" || len([]rune(text)) > 3 {
+		return false
+	}
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// writeAlertMarkers turns the marker paragraphs into GitHub alert markers,
+// with the blank quote line after them removed so the marker stays the
+// first line of the alert.
+func writeAlertMarkers(markdown string) string {
+	if !strings.Contains(markdown, alertMarkerPrefix) {
+		return markdown
+	}
+
+	lines := strings.Split(markdown, "\n")
+	out := lines[:0]
+	skipBlank := ""
+	for _, line := range lines {
+		if skipBlank != "" {
+			blank := strings.TrimRight(line, " ") == strings.TrimRight(skipBlank, " ")
+			skipBlank = ""
+			if blank {
+				continue
+			}
+		}
+		if match := alertMarkerRe.FindStringSubmatch(line); match != nil {
+			out = append(out, match[1]+"[!"+match[2]+"]")
+			skipBlank = match[1]
+			continue
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,321 @@
+package converter
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	xhtml "golang.org/x/net/html"
+)
+
+// gutterSelector matches the line-number gutters of common highlighters:
+// Pygments, Chroma, Prism, highlight.js, GitHub and react-syntax-highlighter.
+const gutterSelector = ".linenos, .lineno, .linenodiv, .ln, .lnt, .line-numbers-rows, " +
+	".hljs-ln-numbers, .blob-num, td.gutter, .react-syntax-highlighter-line-number, .line-number"
+
+// codeContainerSelector matches the elements highlighters wrap code and its
+// gutter in. Gutters are only looked for inside them, since prose may use
+// the same class names.
+const codeContainerSelector = "pre, .highlight, .highlighttable, .codehilite, .chroma, .lntable, .sourceCode, .blob-wrapper"
+
+// lineClasses mark elements that wrap a single line of code.
+var lineClasses = []string{"line", "token-line", "code-line", "highlight-line", "hljs-ln-line"}
+
+var (
+	// languageClassRe matches class names that carry a language:
+	// language-go, lang-go, highlight-source-go (GitHub), highlight-go (Sphinx)
+	languageClassRe = regexp.MustCompile(`^(?:language|lang|highlight-source|highlight)-(.+)$`)
+	// validLanguageRe is what may go in a fence info string
+	validLanguageRe = regexp.MustCompile(`^[a-z0-9][a-z0-9+#._-]*$`)
+	// lineNumbersRe matches the text of a line-number cell
+	lineNumbersRe = regexp.MustCompile(`^[\d\s]*$`)
+)
+
+// languageAliases maps the names highlighters use to one name per language.
+// An empty name means no language.
+var languageAliases = map[string]string{
+	"js":            "javascript",
+	"node":          "javascript",
+	"mjs":           "javascript",
+	"cjs":           "javascript",
+	"ts":            "typescript",
+	"py":            "python",
+	"py3":           "python",
+	"python3":       "python",
+	"sh":            "bash",
+	"shell":         "bash",
+	"shellscript":   "bash",
+	"zsh":           "bash",
+	"shell-session": "console",
+	"shellsession":  "console",
+	"sh-session":    "console",
+	"yml":           "yaml",
+	"golang":        "go",
+	"rb":            "ruby",
+	"rs":            "rust",
+	"kt":            "kotlin",
+	"c++":           "cpp",
+	"cxx":           "cpp",
+	"cs":            "csharp",
+	"c#":            "csharp",
+	"md":            "markdown",
+	"ps1":           "powershell",
+	"pwsh":          "powershell",
+	"docker":        "dockerfile",
+	"objc":          "objectivec",
+	"objective-c":   "objectivec",
+	"htm":           "html",
+	"xhtml":         "html",
+	"tf":            "hcl",
+	"terraform":     "hcl",
+	"plaintext":     "text",
+	"plain":         "text",
+	"txt":           "text",
+	"none":          "",
+	"nohighlight":   "",
+	"default":       "",
+}
+
+// knownLanguages are accepted as bare class names on pre and code, as
+// older highlight.js (class="hljs go") and pandoc (class="sourceCode go")
+// write them.
+var knownLanguages = map[string]bool{
+	"bash": true, "c": true, "clojure": true, "console": true, "cpp": true,
+	"csharp": true, "css": true, "dart": true, "diff": true, "dockerfile": true,
+	"elixir": true, "erlang": true, "go": true, "graphql": true, "haskell": true,
+	"hcl": true, "html": true, "ini": true, "java": true, "javascript": true,
+	"json": true, "jsx": true, "kotlin": true, "lua": true, "makefile": true,
+	"markdown": true, "nginx": true, "objectivec": true, "perl": true, "php": true,
+	"powershell": true, "protobuf": true, "python": true, "r": true, "ruby": true,
+	"rust": true, "scala": true, "scss": true, "sql": true, "svelte": true,
+	"swift": true, "text": true, "toml": true, "tsx": true, "typescript": true,
+	"vue": true, "xml": true, "yaml": true,
+}
+
+// normalizeCodeBlocks rewrites every pre element as a plain <pre><code>
+// holding only the code text, without line-number gutters or per-line
+// wrappers. With syntax highlighting on, the detected language is kept as
+// a language-* class, which becomes the fence info string. It runs before
+// sanitizing, which strips the attributes the language is read from.
+func (c *Converter) normalizeCodeBlocks(content string) string {
+	if !strings.Contains(content, "<pre") {
+		return content
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return content
+	}
+
+	removeGutters(doc)
+
+	doc.Find("pre").Each(func(_ int, pre *goquery.Selection) {
+		if pre.ParentsFiltered("pre").Length() > 0 {
+			return
+		}
+
+		class := ""
+		if c.config.Output.SyntaxHighlighting {
+			if language := detectLanguage(pre); language != "" {
+				class = ` class="language-` + language + `"`
+			}
+		}
+		pre.ReplaceWithHtml("<pre><code" + class + ">" + html.EscapeString(codeText(pre)) + "</code></pre>")
+	})
+
+	body, err := doc.Find("body").Html()
+	if err != nil {
+		return content
+	}
+	return body
+}
+
+// removeGutters drops line numbers. Table layouts (Pygments, Chroma,
+// highlight.js) keep the numbers in their own cell, which is dropped
+// along with the table once only the code is left. Tables are only
+// touched inside a code block or where a row ends in one, so that data
+// tables with numeric columns keep them.
+func removeGutters(doc *goquery.Document) {
+	doc.Find(codeContainerSelector).Find(gutterSelector).Remove()
+
+	doc.Find("table").Each(func(_ int, table *goquery.Selection) {
+		inCode := table.ParentsFiltered("pre").Length() > 0
+		stripped := false
+
+		table.Find("tr").Each(func(_ int, row *goquery.Selection) {
+			cells := row.ChildrenFiltered("td")
+			if cells.Length() < 2 || (!inCode && cells.Last().Find("pre").Length() == 0) {
+				return
+			}
+			cells.Each(func(i int, cell *goquery.Selection) {
+				if i < cells.Length()-1 && lineNumbersRe.MatchString(cell.Text()) {
+					cell.Remove()
+					stripped = true
+				}
+			})
+		})
+
+		inContainer := table.Is(codeContainerSelector) || table.ParentsFiltered(codeContainerSelector).Length() > 0
+		if !stripped && !inContainer {
+			return
+		}
+		if cells := table.Find("td"); cells.Length() == 1 && cells.Find("pre").Length() == 1 {
+			table.ReplaceWithSelection(cells.Contents())
+		}
+	})
+}
+
+// detectLanguage reads the language of a code block from the code element,
+// the pre element or the wrappers around it, closest first.
+func detectLanguage(pre *goquery.Selection) string {
+	candidates := []*goquery.Selection{pre.Find("code").First(), pre}
+	pre.Parents().EachWithBreak(func(i int, parent *goquery.Selection) bool {
+		candidates = append(candidates, parent)
+		return i < 2
+	})
+
+	for i, el := range candidates {
+		if el.Length() == 0 {
+			continue
+		}
+		block := i < 2
+
+		for _, attr := range []string{"data-language", "data-lang"} {
+			if value, ok := el.Attr(attr); ok {
+				if language, ok := normalizeLanguage(value); ok {
+					return language
+				}
+			}
+		}
+		// lang usually holds a natural language, unless GitLab put it on
+		// the code block
+		if value, ok := el.Attr("lang"); ok && block {
+			if language, ok := normalizeLanguage(value); ok && knownLanguages[language] {
+				return language
+			}
+		}
+
+		classes := strings.Fields(el.AttrOr("class", ""))
+		for j, class := range classes {
+			if match := languageClassRe.FindStringSubmatch(class); match != nil {
+				if language, ok := normalizeLanguage(match[1]); ok {
+					return language
+				}
+			}
+			// SyntaxHighlighter: class="brush: js"
+			if class == "brush:" && j+1 < len(classes) {
+				if language, ok := normalizeLanguage(classes[j+1]); ok {
+					return language
+				}
+			}
+			if strings.HasPrefix(class, "brush:") {
+				if language, ok := normalizeLanguage(strings.TrimPrefix(class, "brush:")); ok {
+					return language
+				}
+			}
+		}
+		if block {
+			for _, class := range classes {
+				if language, ok := normalizeLanguage(class); ok && knownLanguages[language] {
+					return language
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// normalizeLanguage maps a language name to its canonical name. It reports
+// false for names that cannot be a language.
+func normalizeLanguage(name string) (string, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := languageAliases[name]; ok {
+		return alias, alias != ""
+	}
+	if !validLanguageRe.MatchString(name) {
+		return "", false
+	}
+	return name, true
+}
+
+// codeText returns the text of a code block with one line per line
+// wrapper, whether the highlighter separates them with newlines, <br>
+// or nothing at all.
+func codeText(pre *goquery.Selection) string {
+	var buf strings.Builder
+	// pending is a line break owed after a line wrapper, dropped if the
+	// text that follows starts with its own newline
+	pending := false
+
+	breakLine := func() {
+		if buf.Len() > 0 && !strings.HasSuffix(buf.String(), "\n") {
+			buf.WriteString("\n")
+		}
+		pending = false
+	}
+
+	var walk func(n *xhtml.Node)
+	walk = func(n *xhtml.Node) {
+		switch n.Type {
+		case xhtml.TextNode:
+			if n.Data == "" {
+				return
+			}
+			if pending && !strings.HasPrefix(n.Data, "\n") {
+				breakLine()
+			}
+			pending = false
+			buf.WriteString(strings.ReplaceAll(n.Data, "\u00a0", " "))
+			return
+		case xhtml.ElementNode:
+			switch n.Data {
+			case "script", "style":
+				return
+			case "br":
+				buf.WriteString("\n")
+				pending = false
+				return
+			}
+		}
+
+		wrapper := n.Type == xhtml.ElementNode && isLineWrapper(n)
+		if wrapper {
+			breakLine()
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+		if wrapper && buf.Len() > 0 && !strings.HasSuffix(buf.String(), "\n") {
+			pending = true
+		}
+	}
+
+	for _, node := range pre.Nodes {
+		walk(node)
+	}
+	return strings.Trim(buf.String(), "\n")
+}
+
+// isLineWrapper reports whether n holds one line of code.
+func isLineWrapper(n *xhtml.Node) bool {
+	switch n.Data {
+	case "div", "p", "tr", "li":
+		return true
+	}
+	for _, attr := range n.Attr {
+		if attr.Key == "data-line" {
+			return true
+		}
+		if attr.Key == "class" {
+			for _, class := range strings.Fields(attr.Val) {
+				for _, lineClass := range lineClasses {
+					if class == lineClass {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
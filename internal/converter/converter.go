@@ -7,6 +7,7 @@ import (
 	md "github.com/JohannesKaufmann/html-to-markdown"
 	"github.com/JohannesKaufmann/html-to-markdown/plugin"
 	"github.com/microcosm-cc/bluemonday"
+	"github.com/vladkampov/markdocify/internal/codefence"
 	"github.com/vladkampov/markdocify/internal/config"
 	"github.com/vladkampov/markdocify/internal/types"
 )
@@ -15,6 +16,7 @@ type Converter struct {
 	config     *config.Config
 	sanitizer  *bluemonday.Policy
 	mdConverter *md.Converter
+	fetchImage  ImageFetcher
 }
 
 
@@ -67,15 +69,27 @@ func (c *Converter) ConvertToMarkdown(page *types.PageContent) (string, error) {
 
 	var content string = page.Content
 
+	content = c.flattenTabs(content)
+	content = c.normalizeCodeBlocks(content)
+	content = c.convertCallouts(content)
+
 	if c.config.Processing.SanitizeHTML {
 		content = c.sanitizer.Sanitize(content)
 	}
 
+	baseURL := page.BaseURL
+	if baseURL == "" {
+		baseURL = page.URL
+	}
+	content = c.resolveLinks(content, baseURL)
+	content = c.rewriteImages(content, baseURL)
+
 	markdown, err := c.mdConverter.ConvertString(content)
 	if err != nil {
 		return "", fmt.Errorf("failed to convert HTML to markdown: %w", err)
 	}
 
+	markdown = writeAlertMarkers(markdown)
 	markdown = c.postProcessMarkdown(markdown)
 
 	if c.config.Output.IncludeMetadata {
@@ -89,8 +103,22 @@ func (c *Converter) ConvertToMarkdown(page *types.PageContent) (string, error) {
 func (c *Converter) postProcessMarkdown(markdown string) string {
 	lines := strings.Split(markdown, "\n")
 	var processedLines []string
+	fence := ""
 
 	for _, line := range lines {
+		// Code blocks are kept exactly as written when preserving them
+		if c.config.Processing.PreserveCodeBlocks {
+			if fence == "" {
+				fence = codefence.Opening(line)
+			} else {
+				if codefence.IsClosing(line, fence) {
+					fence = ""
+				}
+				processedLines = append(processedLines, line)
+				continue
+			}
+		}
+
 		line = strings.TrimRight(line, " \t")
 		
 		if strings.TrimSpace(line) == "" {
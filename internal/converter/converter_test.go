@@ -1,6 +1,7 @@
 package converter
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -351,4 +352,398 @@ func TestSanitizer_ComplexHTML(t *testing.T) {
 	// Should remove dangerous elements
 	assert.NotContains(t, result, "<script>")
 	assert.NotContains(t, result, "alert('malicious')")
-}
\ No newline at end of file
+}
+func TestCodeBlocks(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		expected string
+	}{
+		{
+			name:     "prism class",
+			html:     `<pre class="language-js"><code class="language-js">const a = 1;</code></pre>`,
+			expected: "```javascript\nconst a = 1;\n```",
+		},
+		{
+			name:     "github wrapper",
+			html:     `<div class="highlight highlight-source-python"><pre>print(1)</pre></div>`,
+			expected: "```python\nprint(1)\n```",
+		},
+		{
+			name:     "pandoc",
+			html:     `<div class="sourceCode"><pre class="sourceCode python"><code class="sourceCode python">x = 1</code></pre></div>`,
+			expected: "```python\nx = 1\n```",
+		},
+		{
+			name:     "syntaxhighlighter brush",
+			html:     `<pre class="brush: yml">a: 1</pre>`,
+			expected: "```yaml\na: 1\n```",
+		},
+		{
+			name: "shiki lines without newlines",
+			html: `<pre class="shiki github-dark" data-language="ts"><code><span class="line"><span style="color:#f00">let</span> x</span>` +
+				`<span class="line">x = 2</span></code></pre>`,
+			expected: "```typescript\nlet x\nx = 2\n```",
+		},
+		{
+			name:     "line spans separated by newlines",
+			html:     "<pre><code class=\"language-sh\"><span class=\"line\">a</span>\n<span class=\"line\">b</span>\n</code></pre>",
+			expected: "```bash\na\nb\n```",
+		},
+		{
+			name: "docusaurus token lines",
+			html: `<div class="language-bash codeBlockContainer"><pre class="prism-code"><code>` +
+				`<span class="token-line">npm install<br></span><span class="token-line">npm start<br></span></code></pre></div>`,
+			expected: "```bash\nnpm install\nnpm start\n```",
+		},
+		{
+			name: "chroma inline line numbers",
+			html: "<pre class=\"chroma\"><code class=\"language-go\" data-lang=\"go\">" +
+				"<span class=\"line\"><span class=\"ln\">1</span><span class=\"cl\">x := 1\n</span></span>" +
+				"<span class=\"line\"><span class=\"ln\">2</span><span class=\"cl\">y := 2\n</span></span></code></pre>",
+			expected: "```go\nx := 1\ny := 2\n```",
+		},
+		{
+			name: "pygments line number table",
+			html: "<div class=\"highlight-golang notranslate\"><div class=\"highlight\"><table class=\"highlighttable\"><tr>" +
+				"<td class=\"linenos\"><div class=\"linenodiv\"><pre>1\n2</pre></div></td>" +
+				"<td class=\"code\"><div class=\"highlight\"><pre><span></span>a := 1\nb := 2\n</pre></div></td>" +
+				"</tr></table></div></div>",
+			expected: "```go\na := 1\nb := 2\n```",
+		},
+		{
+			name: "highlight.js line number table",
+			html: `<pre><code class="hljs language-rb"><table class="hljs-ln"><tbody>` +
+				`<tr><td class="hljs-ln-numbers" data-line-number="1"></td><td class="hljs-ln-code"><div class="hljs-ln-line">puts 1</div></td></tr>` +
+				`<tr><td class="hljs-ln-numbers" data-line-number="2"></td><td class="hljs-ln-code"><div class="hljs-ln-line">puts 2</div></td></tr>` +
+				`</tbody></table></code></pre>`,
+			expected: "```ruby\nputs 1\nputs 2\n```",
+		},
+		{
+			name:     "no language",
+			html:     `<pre class="notranslate"><code>plain</code></pre>`,
+			expected: "```\nplain\n```",
+		},
+	}
+
+	for _, sanitize := range []bool{false, true} {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				converter, err := New(&config.Config{
+					Processing: config.ProcessingConfig{SanitizeHTML: sanitize},
+					Output:     config.OutputConfig{SyntaxHighlighting: true},
+				})
+				require.NoError(t, err)
+
+				result, err := converter.ConvertToMarkdown(&types.PageContent{Content: tt.html})
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, result)
+			})
+		}
+	}
+
+	// Without syntax highlighting the fences stay bare
+	converter, err := New(&config.Config{})
+	require.NoError(t, err)
+	result, err := converter.ConvertToMarkdown(&types.PageContent{Content: tests[0].html})
+	require.NoError(t, err)
+	assert.Equal(t, "```\nconst a = 1;\n```", result)
+}
+
+func TestGuttersKeepDataTables(t *testing.T) {
+	converter, err := New(&config.Config{
+		Output: config.OutputConfig{SyntaxHighlighting: true},
+	})
+	require.NoError(t, err)
+
+	input := `<p>Step <span class="ln">1</span> of the guide</p>` +
+		`<table><thead><tr><th>#</th><th>Option</th><th>Description</th></tr></thead><tbody>` +
+		`<tr><td>1</td><td><code>limit</code></td><td>Max items</td></tr>` +
+		`<tr><td>2</td><td><code>offset</code></td><td></td></tr>` +
+		`</tbody></table>` +
+		`<pre><code class="language-go">x := 1</code></pre>`
+
+	result, err := converter.ConvertToMarkdown(&types.PageContent{Content: input})
+	require.NoError(t, err)
+	assert.Contains(t, result, "Step 1 of the guide")
+	assert.Contains(t, result, "| 1 | `limit` | Max items |")
+	assert.Contains(t, result, "| 2 | `offset` |")
+	assert.Contains(t, result, "```go\nx := 1\n```")
+}
+
+func TestPostProcessPreservesCodeBlocks(t *testing.T) {
+	converter := &Converter{config: &config.Config{
+		Processing: config.ProcessingConfig{PreserveCodeBlocks: true},
+	}}
+
+	input := "Text  \n\n\n```python\ndef f():  \n\n\n    pass\n```\n\n\nAfter"
+	assert.Equal(t, "Text\n\n```python\ndef f():  \n\n\n    pass\n```\n\nAfter", converter.postProcessMarkdown(input))
+}
+
+func TestImages(t *testing.T) {
+	html := `<p>Setup</p><p><img src="../img/diagram.png" alt="Architecture diagram"></p><p><img src="https://cdn.example.com/logo.svg" alt=""></p>`
+
+	fetcher := func(imageURL string) (string, error) {
+		if strings.HasPrefix(imageURL, "https://cdn.example.com/") {
+			return "", fmt.Errorf("domain is not allowed")
+		}
+		return "assets/0123456789abcdef.png", nil
+	}
+
+	tests := []struct {
+		name     string
+		mode     string
+		expected string
+	}{
+		{
+			name:     "remote images resolved against the page",
+			mode:     config.ImagesRemote,
+			expected: "Setup\n\n![Architecture diagram](https://example.com/img/diagram.png)\n\n![](https://cdn.example.com/logo.svg)",
+		},
+		{
+			name:     "downloaded images point at assets",
+			mode:     config.ImagesDownload,
+			expected: "Setup\n\n![Architecture diagram](assets/0123456789abcdef.png)\n\n![](https://cdn.example.com/logo.svg)",
+		},
+		{
+			name:     "alt text placeholders",
+			mode:     config.ImagesAltText,
+			expected: "Setup\n\n\\[Image: Architecture diagram\\]\n\n\\[Image\\]",
+		},
+	}
+
+	for _, sanitize := range []bool{false, true} {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				converter, err := New(&config.Config{
+					Processing: config.ProcessingConfig{SanitizeHTML: sanitize},
+					Output:     config.OutputConfig{PreserveImages: true, Images: tt.mode},
+				})
+				require.NoError(t, err)
+				converter.SetImageFetcher(fetcher)
+
+				result, err := converter.ConvertToMarkdown(&types.PageContent{
+					URL:     "https://example.com/docs/setup",
+					Content: html,
+				})
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, result)
+			})
+		}
+	}
+
+	// Images of a page fetched with a trailing slash are fetched relative
+	// to that URL, not its canonical one
+	converter, err := New(&config.Config{
+		Output: config.OutputConfig{PreserveImages: true, Images: config.ImagesDownload},
+	})
+	require.NoError(t, err)
+	var fetched []string
+	converter.SetImageFetcher(func(imageURL string) (string, error) {
+		fetched = append(fetched, imageURL)
+		return "assets/0123456789abcdef.png", nil
+	})
+	_, err = converter.ConvertToMarkdown(&types.PageContent{
+		URL:     "https://example.com/docs/setup",
+		BaseURL: "https://example.com/docs/setup/",
+		Content: `<p><img src="../img/diagram.png" alt="Diagram"></p><p><img src="shot.png" alt="Shot"></p>`,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://example.com/docs/img/diagram.png", "https://example.com/docs/setup/shot.png"}, fetched)
+}
+
+func TestResolveLinks(t *testing.T) {
+	converter, err := New(&config.Config{
+		Processing: config.ProcessingConfig{SanitizeHTML: true},
+	})
+	require.NoError(t, err)
+
+	result, err := converter.ConvertToMarkdown(&types.PageContent{
+		URL: "https://example.com/docs/setup",
+		Content: `<p><a href="../guide#install">Guide</a>, <a href="#options">options</a>, ` +
+			`<a href="https://other.com/">elsewhere</a> and <a href="mailto:docs@example.com">mail</a></p>`,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "[Guide](https://example.com/guide#install), [options](https://example.com/docs/setup#options), "+
+		"[elsewhere](https://other.com/) and [mail](mailto:docs@example.com)", result)
+
+	// A page fetched with a trailing slash resolves against that URL, not
+	// its canonical one
+	result, err = converter.ConvertToMarkdown(&types.PageContent{
+		URL:     "https://example.com/docs/guide",
+		BaseURL: "https://example.com/docs/guide/",
+		Content: `<p><a href="../install/">Install</a> and <a href="setup.html">setup</a></p>`,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "[Install](https://example.com/docs/install/) and [setup](https://example.com/docs/guide/setup.html)", result)
+}
+
+func TestCallouts(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		expected string
+	}{
+		{
+			name: "docusaurus",
+			html: `<div class="theme-admonition theme-admonition-warning admonition_xJq3 alert alert--warning">` +
+				`<div class="admonitionHeading_Gvgb"><span class="admonitionIcon_Rf37"><svg></svg></span>warning</div>` +
+				`<div class="admonitionContent_BuS1"><p>Back up first.</p></div></div>`,
+			expected: "> [!WARNING]\n> Back up first.",
+		},
+		{
+			name: "docusaurus custom title",
+			html: `<div class="admonition admonition-tip alert alert--success"><div class="admonition-heading"><h5>Did you know?</h5></div>` +
+				`<div class="admonition-content"><p>Pages are cached.</p></div></div>`,
+			expected: "> [!TIP]\n> **Did you know?**\n>\n> Pages are cached.",
+		},
+		{
+			name:     "mkdocs",
+			html:     `<div class="admonition danger"><p class="admonition-title">Data loss</p><p>This deletes everything.</p></div>`,
+			expected: "> [!CAUTION]\n> **Data loss**\n>\n> This deletes everything.",
+		},
+		{
+			name:     "sphinx",
+			html:     `<div class="note"><p class="admonition-title">Note</p><p>Requires Python 3.</p></div>`,
+			expected: "> [!NOTE]\n> Requires Python 3.",
+		},
+		{
+			name:     "mkdocs collapsible",
+			html:     `<details class="important"><summary>Read this</summary><p>Keys rotate daily.</p></details>`,
+			expected: "> [!IMPORTANT]\n> **Read this**\n>\n> Keys rotate daily.",
+		},
+		{
+			name: "nextra",
+			html: `<div class="nextra-callout nx-flex nx-rounded-lg"><div class="nx-select-none">💡</div>` +
+				`<div class="nx-w-full"><p>Use the CLI.</p></div></div>`,
+			expected: "> [!TIP]\n> Use the CLI.",
+		},
+		{
+			name:     "mintlify",
+			html:     `<div class="callout my-4" data-callout-type="warning"><div class="callout-icon"><svg></svg></div><div class="callout-content"><p>Rate limited.</p></div></div>`,
+			expected: "> [!WARNING]\n> Rate limited.",
+		},
+		{
+			name:     "configured class",
+			html:     `<div class="box box-red"><p>Never share your key.</p></div>`,
+			expected: "> [!CAUTION]\n> Never share your key.",
+		},
+		{
+			name:     "plain div",
+			html:     `<div class="summary"><p>Just a summary.</p></div>`,
+			expected: "Just a summary.",
+		},
+	}
+
+	for _, sanitize := range []bool{false, true} {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				converter, err := New(&config.Config{
+					Processing: config.ProcessingConfig{SanitizeHTML: sanitize},
+					Output: config.OutputConfig{
+						AlertClasses: map[string]string{"box-red": "caution"},
+					},
+				})
+				require.NoError(t, err)
+
+				result, err := converter.ConvertToMarkdown(&types.PageContent{Content: tt.html})
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, result)
+			})
+		}
+	}
+}
+
+func TestTabs(t *testing.T) {
+	docusaurus := `<div class="tabs-container"><ul role="tablist" class="tabs"><li role="tab" class="tabs__item tabs__item--active">npm</li>` +
+		`<li role="tab" class="tabs__item">Yarn</li></ul><div class="margin-top--md">` +
+		`<div role="tabpanel" class="tabItem_Ymn6"><pre><code class="language-bash">npm install markdocify</code></pre></div>` +
+		`<div role="tabpanel" class="tabItem_Ymn6" hidden><pre><code class="language-bash">yarn add markdocify</code></pre></div></div></div>`
+
+	radix := `<div dir="ltr" data-orientation="horizontal"><div role="tablist" aria-orientation="horizontal">` +
+		`<button role="tab" id="t-curl" aria-controls="p-curl" data-state="active"><svg></svg>curl</button>` +
+		`<button role="tab" id="t-go" aria-controls="p-go">Go</button>` +
+		`<button role="tab" id="t-ruby" aria-controls="p-ruby">Ruby</button></div>` +
+		`<div role="tabpanel" id="p-curl" aria-labelledby="t-curl"><pre><code class="language-bash">curl https://api.example.com</code></pre></div>` +
+		`<div role="tabpanel" id="p-go" aria-labelledby="t-go"><pre><code class="language-go">client.Get()</code></pre></div>` +
+		`<div role="tabpanel" id="p-ruby" aria-labelledby="t-ruby"></div></div>`
+
+	mkdocs := `<div class="tabbed-set tabbed-alternate"><input checked id="__tabbed_1_1" type="radio"><input id="__tabbed_1_2" type="radio">` +
+		`<div class="tabbed-labels"><label for="__tabbed_1_1">Python</label><label for="__tabbed_1_2">Java</label></div>` +
+		`<div class="tabbed-content"><div class="tabbed-block"><pre><code class="language-python">print(1)</code></pre></div>` +
+		`<div class="tabbed-block"><p>Use <code>System.out</code>.</p></div></div></div>`
+
+	vitepress := `<div class="vp-code-group"><div class="tabs"><input type="radio" id="tab-1" checked><label for="tab-1">npm</label>` +
+		`<input type="radio" id="tab-2"><label for="tab-2">pnpm</label></div><div class="blocks">` +
+		`<div class="language-sh active"><pre><code>npm i vue</code></pre></div>` +
+		`<div class="language-sh"><pre><code>pnpm add vue</code></pre></div></div></div>`
+
+	siblings := `<div><ul role="tablist"><li role="tab">npm</li><li role="tab">Yarn</li></ul>` +
+		`<div role="tabpanel"><p>npm install</p></div><div role="tabpanel" hidden><p>yarn add</p></div>` +
+		`<ul role="tablist"><li role="tab">macOS</li><li role="tab">Linux</li></ul>` +
+		`<div role="tabpanel"><p>brew install</p></div><div role="tabpanel" hidden><p>apt install</p></div></div>`
+
+	tests := []struct {
+		name      string
+		html      string
+		preferred []string
+		expected  string
+	}{
+		{
+			name:     "sibling widgets keep their own panels",
+			html:     siblings,
+			expected: "**npm**\n\nnpm install\n\n**Yarn**\n\nyarn add\n\n**macOS**\n\nbrew install\n\n**Linux**\n\napt install",
+		},
+		{
+			name:     "docusaurus",
+			html:     docusaurus,
+			expected: "**npm**\n\n```bash\nnpm install markdocify\n```\n\n**Yarn**\n\n```bash\nyarn add markdocify\n```",
+		},
+		{
+			name:     "radix skips unrendered panels",
+			html:     radix,
+			expected: "**curl**\n\n```bash\ncurl https://api.example.com\n```\n\n**Go**\n\n```go\nclient.Get()\n```",
+		},
+		{
+			name:      "preferred tabs",
+			html:      radix,
+			preferred: []string{"go"},
+			expected:  "**Go**\n\n```go\nclient.Get()\n```",
+		},
+		{
+			name:      "no preferred tab keeps all",
+			html:      docusaurus,
+			preferred: []string{"curl", "go"},
+			expected:  "**npm**\n\n```bash\nnpm install markdocify\n```\n\n**Yarn**\n\n```bash\nyarn add markdocify\n```",
+		},
+		{
+			name:     "mkdocs",
+			html:     mkdocs,
+			expected: "**Python**\n\n```python\nprint(1)\n```\n\n**Java**\n\nUse `System.out`.",
+		},
+		{
+			name:     "vitepress",
+			html:     vitepress,
+			expected: "**npm**\n\n```bash\nnpm i vue\n```\n\n**pnpm**\n\n```bash\npnpm add vue\n```",
+		},
+	}
+
+	for _, sanitize := range []bool{false, true} {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				converter, err := New(&config.Config{
+					Processing: config.ProcessingConfig{SanitizeHTML: sanitize},
+					Output: config.OutputConfig{
+						SyntaxHighlighting: true,
+						PreferredTabs:      tt.preferred,
+					},
+				})
+				require.NoError(t, err)
+
+				result, err := converter.ConvertToMarkdown(&types.PageContent{Content: tt.html})
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, result)
+			})
+		}
+	}
+}
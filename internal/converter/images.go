@@ -0,0 +1,81 @@
+package converter
+
+import (
+	"html"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/vladkampov/markdocify/internal/config"
+)
+
+// ImageFetcher stores the image at an absolute URL and returns the src to
+// write in its place: a path relative to the output file or a data URI.
+type ImageFetcher func(imageURL string) (string, error)
+
+// SetImageFetcher sets how images are stored in the download and data_uri
+// image modes. Without one, images keep their original URL.
+func (c *Converter) SetImageFetcher(fetch ImageFetcher) {
+	c.fetchImage = fetch
+}
+
+// rewriteImages points every image of the page at where the image mode
+// puts it. Relative sources are resolved against the page's base URL, so
+// that remote images still load once the output is moved; images that
+// cannot be stored keep that URL.
+func (c *Converter) rewriteImages(content, baseURL string) string {
+	if !c.config.Output.PreserveImages || !strings.Contains(content, "<img") {
+		return content
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return content
+	}
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		base = &url.URL{}
+	}
+
+	doc.Find("img").Each(func(_ int, img *goquery.Selection) {
+		if c.config.Output.Images == config.ImagesAltText {
+			img.ReplaceWithHtml(altTextPlaceholder(img))
+			return
+		}
+
+		src, ok := resolveURL(base, img.AttrOr("src", ""))
+		if !ok || strings.HasPrefix(src, "data:") {
+			return
+		}
+		img.SetAttr("src", src)
+
+		switch c.config.Output.Images {
+		case config.ImagesDownload, config.ImagesDataURI:
+			if c.fetchImage == nil {
+				return
+			}
+			if stored, err := c.fetchImage(src); err == nil {
+				img.SetAttr("src", stored)
+			}
+		}
+	})
+
+	body, err := doc.Find("body").Html()
+	if err != nil {
+		return content
+	}
+	return body
+}
+
+// altTextPlaceholder describes an image by its alt text, or its title when
+// it has none.
+func altTextPlaceholder(img *goquery.Selection) string {
+	text := strings.TrimSpace(img.AttrOr("alt", ""))
+	if text == "" {
+		text = strings.TrimSpace(img.AttrOr("title", ""))
+	}
+	if text == "" {
+		return "[Image]"
+	}
+	return "[Image: " + html.EscapeString(text) + "]"
+}
@@ -0,0 +1,53 @@
+package converter
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// resolveLinks makes the links of a page absolute, resolving them against
+// the page's base URL, so that links to pages left out of the output still work
+// and the aggregator can tell which ones lead to pages it has. Links to a
+// fragment of the page itself get the page URL too.
+func (c *Converter) resolveLinks(content, baseURL string) string {
+	if baseURL == "" || !strings.Contains(content, "<a") {
+		return content
+	}
+
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return content
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return content
+	}
+
+	doc.Find("a[href]").Each(func(_ int, a *goquery.Selection) {
+		if href, ok := resolveURL(base, a.AttrOr("href", "")); ok {
+			a.SetAttr("href", href)
+		}
+	})
+
+	body, err := doc.Find("body").Html()
+	if err != nil {
+		return content
+	}
+	return body
+}
+
+// resolveURL resolves ref against base. It reports false for references
+// that are empty or cannot be parsed.
+func resolveURL(base *url.URL, ref string) (string, bool) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return "", false
+	}
+	parsed, err := url.Parse(ref)
+	if err != nil {
+		return "", false
+	}
+	return base.ResolveReference(parsed).String(), true
+}
@@ -0,0 +1,214 @@
+package converter
+
+import (
+	"html"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// labeledTabSets are tab widgets that label their panels with <label>
+// elements instead of ARIA roles: the set, then its labels and its panels
+// in the same order. Of several label or panel selectors, the first that
+// matches is used.
+var labeledTabSets = []struct {
+	set    string
+	labels []string
+	panels []string
+}{
+	// MkDocs Material content tabs, in their current and older layout
+	{".tabbed-set", []string{".tabbed-labels > label", ".tabbed-set > label"}, []string{".tabbed-block", ".tabbed-content"}},
+	// VitePress code groups
+	{".vp-code-group", []string{".tabs > label"}, []string{".blocks > div"}},
+}
+
+// tab is a tab panel with its label.
+type tab struct {
+	label string
+	panel *goquery.Selection
+}
+
+// flattenTabs writes out every panel of a tab widget, each under its tab
+// label in bold, where the page would only show one of them at a time.
+// With preferred_tabs set, only the matching panels are kept. It runs
+// before sanitizing, which strips the roles and classes tabs are
+// recognized by.
+func (c *Converter) flattenTabs(content string) string {
+	if !strings.Contains(content, "tablist") && !strings.Contains(content, "tabbed-set") &&
+		!strings.Contains(content, "vp-code-group") {
+		return content
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return content
+	}
+
+	for _, kind := range labeledTabSets {
+		sets := doc.Find(kind.set)
+		// Innermost first, so an outer set holds the flattened inner one
+		for i := sets.Length() - 1; i >= 0; i-- {
+			set := sets.Eq(i)
+			var tabs []tab
+			labels := findFirst(set, kind.labels)
+			findFirst(set, kind.panels).Each(func(j int, panel *goquery.Selection) {
+				tabs = append(tabs, tab{label: tabLabel(labels.Eq(j)), panel: panel})
+			})
+			if len(tabs) > 0 {
+				set.ReplaceWithHtml(c.tabsHTML(tabs))
+			}
+		}
+	}
+
+	tablists := doc.Find("[role=tablist]")
+	for i := tablists.Length() - 1; i >= 0; i-- {
+		tablist := tablists.Eq(i)
+		tabs := ariaTabs(tablist)
+		if len(tabs) == 0 {
+			continue
+		}
+		tablist.ReplaceWithHtml(c.tabsHTML(tabs))
+		for _, t := range tabs {
+			t.panel.Remove()
+		}
+	}
+
+	body, err := doc.Find("body").Html()
+	if err != nil {
+		return content
+	}
+	return body
+}
+
+// findFirst returns the elements matching the first selector that matches
+// any within el.
+func findFirst(el *goquery.Selection, selectors []string) *goquery.Selection {
+	var found *goquery.Selection
+	for _, selector := range selectors {
+		if found = el.Find(selector); found.Length() > 0 {
+			break
+		}
+	}
+	return found
+}
+
+// ariaTabs pairs the tabs of an ARIA tablist with their panels, found in
+// the closest ancestor holding any. Tabs name their panel through
+// aria-controls, panels their tab through aria-labelledby; otherwise they
+// are paired in order, as Docusaurus renders them, with the panels between
+// the tablist and the next one.
+func ariaTabs(tablist *goquery.Selection) []tab {
+	var container, panels *goquery.Selection
+	for el, up := tablist.Parent(), 0; el.Length() > 0 && up < 4; el, up = el.Parent(), up+1 {
+		if found := el.Find("[role=tabpanel]"); found.Length() > 0 {
+			container, panels = el, found
+			break
+		}
+	}
+	if panels == nil {
+		return nil
+	}
+
+	byID := make(map[string]*goquery.Selection)
+	byTab := make(map[string]*goquery.Selection)
+	panels.Each(func(_ int, panel *goquery.Selection) {
+		if id := panel.AttrOr("id", ""); id != "" {
+			byID[id] = panel
+		}
+		if tabID := panel.AttrOr("aria-labelledby", ""); tabID != "" {
+			byTab[tabID] = panel
+		}
+	})
+	following := followingPanels(container, tablist)
+
+	var tabs []tab
+	tablist.Find("[role=tab]").Each(func(i int, el *goquery.Selection) {
+		panel := byID[el.AttrOr("aria-controls", "")]
+		if panel == nil {
+			panel = byTab[el.AttrOr("id", "")]
+		}
+		if panel == nil && i < len(following) {
+			panel = following[i]
+		}
+		if panel != nil {
+			tabs = append(tabs, tab{label: tabLabel(el), panel: panel})
+		}
+	})
+	return tabs
+}
+
+// followingPanels returns the tab panels in container after tablist and
+// before the next tablist, so that sibling widgets keep their own panels.
+func followingPanels(container, tablist *goquery.Selection) []*goquery.Selection {
+	var panels []*goquery.Selection
+	after := false
+	container.Find("[role=tablist], [role=tabpanel]").EachWithBreak(func(_ int, el *goquery.Selection) bool {
+		if el.Is("[role=tablist]") {
+			if after {
+				return false
+			}
+			after = el.IsSelection(tablist)
+			return true
+		}
+		if after {
+			panels = append(panels, el)
+		}
+		return true
+	})
+	return panels
+}
+
+// tabsHTML writes the panels to keep, each after its label. Panels keep
+// their classes, which may name the language of their code, but no longer
+// count as tab panels. Panels a framework left empty because they were
+// never shown are skipped.
+func (c *Converter) tabsHTML(tabs []tab) string {
+	kept := c.preferredTabs(tabs)
+
+	var b strings.Builder
+	for _, t := range kept {
+		if strings.TrimSpace(t.panel.Text()) == "" && t.panel.Find("img").Length() == 0 {
+			continue
+		}
+		t.panel.RemoveAttr("role").RemoveAttr("hidden")
+		panel, err := goquery.OuterHtml(t.panel)
+		if err != nil {
+			continue
+		}
+		if t.label != "" {
+			b.WriteString("<p><strong>" + html.EscapeString(t.label) + "</strong></p>")
+		}
+		b.WriteString(panel)
+	}
+	return b.String()
+}
+
+// preferredTabs returns the tabs whose label is one of preferred_tabs. A
+// widget with none of them keeps all its tabs, so that no example is lost.
+func (c *Converter) preferredTabs(tabs []tab) []tab {
+	preferred := c.config.Output.PreferredTabs
+	if len(preferred) == 0 {
+		return tabs
+	}
+
+	var kept []tab
+	for _, t := range tabs {
+		for _, name := range preferred {
+			if strings.EqualFold(strings.TrimSpace(name), t.label) {
+				kept = append(kept, t)
+				break
+			}
+		}
+	}
+	if len(kept) == 0 {
+		return tabs
+	}
+	return kept
+}
+
+// tabLabel returns the text of a tab, without its icons.
+func tabLabel(el *goquery.Selection) string {
+	el = el.Clone()
+	el.Find("svg, img").Remove()
+	return strings.Join(strings.Fields(el.Text()), " ")
+}
@@ -0,0 +1,159 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Outcome is what happened to a URL during a crawl.
+type Outcome string
+
+const (
+	OutcomeConverted        Outcome = "converted"
+	OutcomeNotModified      Outcome = "not_modified"
+	OutcomeDuplicate        Outcome = "duplicate_content"
+	OutcomeCanonicalAlias   Outcome = "canonical_alias"
+	OutcomeIgnored          Outcome = "skipped_ignore_pattern"
+	OutcomeNotFollowed      Outcome = "skipped_follow_pattern"
+	OutcomeSkipCategory     Outcome = "skipped_category"
+	OutcomeDisallowedDomain Outcome = "disallowed_domain"
+	OutcomeRobots           Outcome = "robots_disallowed"
+	OutcomeNoContent        Outcome = "no_content"
+	OutcomeConversionError  Outcome = "conversion_error"
+	OutcomeHTTPError        Outcome = "http_error"
+	OutcomeTooLarge         Outcome = "too_large"
+	OutcomeOverBudget       Outcome = "over_budget"
+	OutcomeMaxDepth         Outcome = "skipped_max_depth"
+	OutcomeDiscovered       Outcome = "discovered"
+	OutcomePending          Outcome = "pending"
+)
+
+// Entry describes one URL seen during a crawl. Rule is what accepted the
+// URL for crawling; Reason details the outcome, such as the rule that
+// rejected a skipped URL.
+type Entry struct {
+	URL           string     `json:"url"`
+	Outcome       Outcome    `json:"outcome"`
+	Reason        string     `json:"reason,omitempty"`
+	Rule          string     `json:"rule,omitempty"`
+	Depth         int        `json:"depth"`
+	Parent        string     `json:"parent,omitempty"`
+	StatusCode    int        `json:"status_code,omitempty"`
+	Attempts      int        `json:"attempts,omitempty"`
+	ResponseBytes int        `json:"response_bytes,omitempty"`
+	MarkdownBytes int        `json:"markdown_bytes,omitempty"`
+	Error         string     `json:"error,omitempty"`
+	FetchedAt     *time.Time `json:"fetched_at,omitempty"`
+	FetchMS       int64      `json:"fetch_ms,omitempty"`
+	ConvertMS     int64      `json:"convert_ms,omitempty"`
+}
+
+// Report collects per-URL outcomes of a crawl. It is safe for concurrent use.
+type Report struct {
+	mu      sync.Mutex
+	entries map[string]*Entry
+}
+
+// Document is the JSON layout of a written report.
+type Document struct {
+	Name        string          `json:"name"`
+	GeneratedAt time.Time       `json:"generated_at"`
+	Complete    bool            `json:"complete"`
+	Summary     map[Outcome]int `json:"summary"`
+	URLs        []*Entry        `json:"urls"`
+}
+
+func New() *Report {
+	return &Report{entries: make(map[string]*Entry)}
+}
+
+// Update applies fn to the entry for url, creating a pending entry first
+// if the URL has not been seen yet.
+func (r *Report) Update(url string, fn func(e *Entry)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.entries[url]
+	if !ok {
+		entry = &Entry{URL: url, Outcome: OutcomePending}
+		r.entries[url] = entry
+	}
+	fn(entry)
+}
+
+// Skip records that url was not fetched, unless it already has an outcome.
+// Only the first parent linking to a skipped URL is kept.
+func (r *Report) Skip(url, parent string, depth int, outcome Outcome, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.entries[url]; ok {
+		return
+	}
+	r.entries[url] = &Entry{
+		URL:     url,
+		Outcome: outcome,
+		Reason:  reason,
+		Depth:   depth,
+		Parent:  parent,
+	}
+}
+
+// Get returns a copy of the entry for url.
+func (r *Report) Get(url string) (Entry, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.entries[url]
+	if !ok {
+		return Entry{}, false
+	}
+	return *entry, true
+}
+
+// Document builds the report with entries sorted by URL.
+func (r *Report) Document(name string, complete bool) *Document {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	doc := &Document{
+		Name:        name,
+		GeneratedAt: time.Now(),
+		Complete:    complete,
+		Summary:     make(map[Outcome]int),
+		URLs:        make([]*Entry, 0, len(r.entries)),
+	}
+	for _, entry := range r.entries {
+		copied := *entry
+		doc.URLs = append(doc.URLs, &copied)
+		doc.Summary[entry.Outcome]++
+	}
+	sort.Slice(doc.URLs, func(i, j int) bool {
+		return doc.URLs[i].URL < doc.URLs[j].URL
+	})
+	return doc
+}
+
+// Write saves the report as indented JSON to path.
+func (r *Report) Write(path, name string, complete bool) error {
+	data, err := json.MarshalIndent(r.Document(name, complete), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return fmt.Errorf("failed to create report directory: %w", err)
+		}
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
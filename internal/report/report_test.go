@@ -0,0 +1,100 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateAndSkip(t *testing.T) {
+	r := New()
+
+	r.Update("https://example.com/a", func(e *Entry) {
+		e.Depth = 1
+	})
+	entry, ok := r.Get("https://example.com/a")
+	require.True(t, ok)
+	assert.Equal(t, OutcomePending, entry.Outcome)
+
+	r.Update("https://example.com/a", func(e *Entry) {
+		e.Outcome = OutcomeConverted
+	})
+	entry, _ = r.Get("https://example.com/a")
+	assert.Equal(t, OutcomeConverted, entry.Outcome)
+	assert.Equal(t, 1, entry.Depth)
+
+	// Skips never override a URL that was already seen
+	r.Skip("https://example.com/a", "https://example.com/", 2, OutcomeIgnored, ".*")
+	entry, _ = r.Get("https://example.com/a")
+	assert.Equal(t, OutcomeConverted, entry.Outcome)
+
+	r.Skip("https://example.com/login", "https://example.com/a", 2, OutcomeSkipCategory, "")
+	r.Skip("https://example.com/login", "https://example.com/b", 2, OutcomeSkipCategory, "")
+	entry, _ = r.Get("https://example.com/login")
+	assert.Equal(t, "https://example.com/a", entry.Parent, "First parent is kept")
+}
+
+func TestWrite(t *testing.T) {
+	r := New()
+	r.Update("https://example.com/b", func(e *Entry) { e.Outcome = OutcomeConverted })
+	r.Update("https://example.com/a", func(e *Entry) { e.Outcome = OutcomeConverted })
+	r.Skip("https://example.com/c", "https://example.com/a", 2, OutcomeNoContent, "")
+
+	path := filepath.Join(t.TempDir(), "reports", "crawl.json")
+	require.NoError(t, r.Write(path, "Docs", true))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var doc Document
+	require.NoError(t, json.Unmarshal(data, &doc))
+	assert.Equal(t, "Docs", doc.Name)
+	assert.True(t, doc.Complete)
+	assert.Equal(t, map[Outcome]int{OutcomeConverted: 2, OutcomeNoContent: 1}, doc.Summary)
+	require.Len(t, doc.URLs, 3)
+	assert.Equal(t, "https://example.com/a", doc.URLs[0].URL)
+	assert.Equal(t, "https://example.com/c", doc.URLs[2].URL)
+}
+
+func TestTree(t *testing.T) {
+	r := New()
+	r.Update("https://example.com/", func(e *Entry) {
+		e.Outcome = OutcomeDiscovered
+		e.Depth = 1
+		e.Rule = "start URL"
+	})
+	r.Update("https://example.com/b", func(e *Entry) {
+		e.Outcome = OutcomeDiscovered
+		e.Depth = 2
+		e.Parent = "https://example.com/"
+		e.Rule = "no follow patterns"
+	})
+	r.Skip("https://example.com/a.pdf", "https://example.com/", 2, OutcomeIgnored, `\.pdf$`)
+	r.Skip("https://example.com/b/c", "https://example.com/b", 3, OutcomeMaxDepth, "max_depth 2")
+	// A cycle, as left by a page naming its own alias canonical
+	r.Update("https://example.com/x", func(e *Entry) { e.Parent = "https://example.com/y"; e.Depth = 1 })
+	r.Update("https://example.com/y", func(e *Entry) { e.Parent = "https://example.com/x"; e.Depth = 2 })
+
+	tree := r.Document("Docs", true).Tree()
+	require.Len(t, tree, 2)
+	assert.Equal(t, "https://example.com/", tree[0].URL)
+	require.Len(t, tree[0].Children, 2)
+	assert.Equal(t, "https://example.com/a.pdf", tree[0].Children[0].URL)
+	assert.Equal(t, "https://example.com/b/c", tree[0].Children[1].Children[0].URL)
+	assert.Equal(t, "https://example.com/x", tree[1].URL)
+	assert.Equal(t, "https://example.com/y", tree[1].Children[0].URL)
+	assert.Empty(t, tree[1].Children[0].Children)
+
+	var out bytes.Buffer
+	require.NoError(t, WriteTree(&out, tree[:1]))
+	assert.Equal(t, `https://example.com/  depth=1 discovered rule="start URL"
+├── https://example.com/a.pdf  depth=2 skipped_ignore_pattern reason="\\.pdf$"
+└── https://example.com/b  depth=2 discovered rule="no follow patterns"
+    └── https://example.com/b/c  depth=3 skipped_max_depth reason="max_depth 2"
+`, out.String())
+}
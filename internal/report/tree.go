@@ -0,0 +1,114 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Node is a URL placed under the page it was first found on.
+type Node struct {
+	URL      string  `json:"url"`
+	Depth    int     `json:"depth"`
+	Outcome  Outcome `json:"outcome"`
+	Rule     string  `json:"rule,omitempty"`
+	Reason   string  `json:"reason,omitempty"`
+	Children []*Node `json:"children,omitempty"`
+}
+
+// Tree arranges the URLs of the document under their parents. URLs with no
+// known parent, such as start URLs, are the roots. Siblings are ordered by
+// URL and roots by depth, then URL.
+func (d *Document) Tree() []*Node {
+	entries := make([]*Entry, len(d.URLs))
+	copy(entries, d.URLs)
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].URL < entries[j].URL
+	})
+
+	nodes := make(map[string]*Node, len(entries))
+	for _, e := range entries {
+		nodes[e.URL] = &Node{URL: e.URL, Depth: e.Depth, Outcome: e.Outcome, Rule: e.Rule, Reason: e.Reason}
+	}
+
+	parents := make(map[*Node]*Node)
+	var roots []*Node
+	for _, e := range entries {
+		node := nodes[e.URL]
+		if parent, ok := nodes[e.Parent]; ok && parent != node {
+			parent.Children = append(parent.Children, node)
+			parents[node] = parent
+			continue
+		}
+		roots = append(roots, node)
+	}
+
+	// A canonical URL can end up as the parent of the page naming it, so
+	// URLs caught in a cycle are cut loose and become roots
+	reached := make(map[*Node]bool)
+	var reach func(n *Node)
+	reach = func(n *Node) {
+		reached[n] = true
+		for _, child := range n.Children {
+			reach(child)
+		}
+	}
+	for _, root := range roots {
+		reach(root)
+	}
+	for _, e := range entries {
+		node := nodes[e.URL]
+		if reached[node] {
+			continue
+		}
+		parent := parents[node]
+		for i, child := range parent.Children {
+			if child == node {
+				parent.Children = append(parent.Children[:i], parent.Children[i+1:]...)
+				break
+			}
+		}
+		roots = append(roots, node)
+		reach(node)
+	}
+
+	sort.SliceStable(roots, func(i, j int) bool {
+		if roots[i].Depth != roots[j].Depth {
+			return roots[i].Depth < roots[j].Depth
+		}
+		return roots[i].URL < roots[j].URL
+	})
+	return roots
+}
+
+// WriteTree writes the tree as text, one URL per line with its depth,
+// outcome and the rule that accepted it or the reason it was skipped.
+func WriteTree(w io.Writer, roots []*Node) error {
+	var out strings.Builder
+	var write func(n *Node, prefix, branch, indent string)
+	write = func(n *Node, prefix, branch, indent string) {
+		out.WriteString(fmt.Sprintf("%s%s%s  depth=%d %s", prefix, branch, n.URL, n.Depth, n.Outcome))
+		if n.Rule != "" {
+			out.WriteString(fmt.Sprintf(" rule=%q", n.Rule))
+		}
+		if n.Reason != "" {
+			out.WriteString(fmt.Sprintf(" reason=%q", n.Reason))
+		}
+		out.WriteString("\n")
+
+		for i, child := range n.Children {
+			if i == len(n.Children)-1 {
+				write(child, prefix+indent, "└── ", "    ")
+			} else {
+				write(child, prefix+indent, "├── ", "│   ")
+			}
+		}
+	}
+	for _, root := range roots {
+		write(root, "", "", "")
+	}
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
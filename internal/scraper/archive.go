@@ -0,0 +1,55 @@
+package scraper
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vladkampov/markdocify/internal/warc"
+)
+
+// ArchiveSoftware identifies markdocify in the warcinfo record.
+const ArchiveSoftware = "markdocify"
+
+// openReplay loads the WARC file replayed by the warc source.
+func (s *Scraper) openReplay() error {
+	archive, err := warc.Open(s.config.Source.Path)
+	if err != nil {
+		return err
+	}
+	if archive.Len() == 0 {
+		return fmt.Errorf("%s contains no responses", s.config.Source.Path)
+	}
+	s.replay = archive
+
+	s.logger.WithFields(logrus.Fields{
+		"path":        s.config.Source.Path,
+		"urls":        archive.Len(),
+		"archived_at": archive.Date().Format(time.RFC3339),
+	}).Info("📼 Replaying WARC archive")
+	return nil
+}
+
+// archiveDate returns the date stamped on the output of archived and
+// replayed crawls, so both produce the same bytes.
+func (s *Scraper) archiveDate() time.Time {
+	switch {
+	case s.replay != nil:
+		return s.replay.Date()
+	case s.archive != nil:
+		return s.archive.Date()
+	}
+	return time.Time{}
+}
+
+// closeArchive closes the WARC file being written, if any.
+func (s *Scraper) closeArchive() {
+	if s.archive == nil {
+		return
+	}
+	if err := s.archive.Close(); err != nil {
+		s.logger.WithError(err).Warn("Failed to close WARC file")
+		return
+	}
+	s.logger.WithField("warc_file", s.config.WARCFile).Info("📼 WARC archive written")
+}
@@ -0,0 +1,144 @@
+package scraper
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/vladkampov/markdocify/internal/config"
+)
+
+// indexFiles are resolved to their directory URL.
+var indexFiles = []string{"index.html", "index.htm"}
+
+// canonicalizer normalizes URLs so that every variant of a page maps to
+// the same key before deduplication and visiting.
+type canonicalizer struct {
+	rules config.CanonicalConfig
+}
+
+func newCanonicalizer(rules config.CanonicalConfig) *canonicalizer {
+	return &canonicalizer{rules: rules}
+}
+
+// canonicalize returns the canonical form of rawURL. URLs that cannot be
+// parsed are returned unchanged.
+func (c *canonicalizer) canonicalize(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if (u.Scheme == "http" && u.Port() == "80") || (u.Scheme == "https" && u.Port() == "443") {
+		u.Host = strings.TrimSuffix(u.Host, ":"+u.Port())
+	}
+
+	if !c.rules.KeepFragments {
+		u.Fragment = ""
+		u.RawFragment = ""
+	}
+
+	// Re-encoding also sorts the remaining parameters
+	if u.RawQuery != "" {
+		query := u.Query()
+		for name := range query {
+			if c.dropParam(name) {
+				query.Del(name)
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	if !c.rules.KeepIndexFiles {
+		for _, index := range indexFiles {
+			if path.Base(u.Path) == index {
+				u.Path = strings.TrimSuffix(u.Path, index)
+				break
+			}
+		}
+	}
+
+	switch c.rules.TrailingSlash {
+	case config.TrailingSlashStrip:
+		if u.Path != "/" {
+			u.Path = strings.TrimRight(u.Path, "/")
+		}
+	case config.TrailingSlashAdd:
+		// Paths that look like files keep their form
+		if !strings.HasSuffix(u.Path, "/") && !strings.Contains(path.Base(u.Path), ".") {
+			u.Path += "/"
+		}
+	}
+	u.RawPath = ""
+
+	return u.String()
+}
+
+func (c *canonicalizer) dropParam(name string) bool {
+	for _, pattern := range c.rules.DropQueryParams {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalURL canonicalizes rawURL and records it as an alias of the
+// result when the two differ.
+func (s *Scraper) canonicalURL(rawURL string) string {
+	canonical := s.canonicalizer.canonicalize(rawURL)
+	s.recordAlias(canonical, rawURL)
+	return canonical
+}
+
+func (s *Scraper) recordAlias(canonical, alias string) {
+	if alias == canonical {
+		return
+	}
+	s.logger.WithFields(logrus.Fields{
+		"url":       alias,
+		"canonical": canonical,
+	}).Debug("Mapped URL to canonical page")
+	s.aggregator.AddAlias(canonical, alias)
+}
+
+// relCanonical returns the canonical URL a page declares with
+// <link rel="canonical">, or "" if it declares none that may be crawled.
+func (s *Scraper) relCanonical(e *colly.HTMLElement) string {
+	if s.config.Canonicalize.IgnoreRelCanonical {
+		return ""
+	}
+
+	href := strings.TrimSpace(e.ChildAttr(`link[rel="canonical"]`, "href"))
+	if href == "" {
+		return ""
+	}
+
+	canonical := s.canonicalizer.canonicalize(e.Request.AbsoluteURL(href))
+	if canonical == "" || !s.isAllowedDomain(canonical) {
+		return ""
+	}
+	return canonical
+}
+
+// logLinkSummary reports how many links of the output were turned into
+// anchors of aggregated pages and how many still lead off the document.
+func (s *Scraper) logLinkSummary() {
+	internal, external := s.aggregator.LinkStats()
+	s.logger.WithFields(logrus.Fields{
+		"internal_links": internal,
+		"external_links": external,
+	}).Info("🔗 Linked pages within the document")
+}
@@ -0,0 +1,237 @@
+package scraper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vladkampov/markdocify/internal/aggregator"
+)
+
+const (
+	CheckpointFileName        = "checkpoint.json"
+	DefaultCheckpointInterval = 30 * time.Second
+)
+
+// checkpoint is the on-disk state of a crawl.
+type checkpoint struct {
+	Name      string             `json:"name"`
+	UpdatedAt time.Time          `json:"updated_at"`
+	Completed bool               `json:"completed"`
+	Visited   []string           `json:"visited"`
+	Pending   []pendingURL       `json:"pending"`
+	Pages     []*aggregator.Page `json:"pages"`
+	// Navigation is the tree read from start pages, which are not
+	// fetched again on resume
+	Navigation []*aggregator.NavItem `json:"navigation,omitempty"`
+}
+
+type pendingURL struct {
+	URL   string `json:"url"`
+	Depth int    `json:"depth"`
+}
+
+// frontier tracks URLs that were queued but whose processing, including
+// everything reached through them, has not finished yet.
+type frontier struct {
+	mu      sync.Mutex
+	pending map[string]int
+}
+
+func newFrontier() *frontier {
+	return &frontier{pending: make(map[string]int)}
+}
+
+func (f *frontier) add(url string, depth int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.pending[url]; !ok {
+		f.pending[url] = depth
+	}
+}
+
+func (f *frontier) done(url string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.pending, url)
+}
+
+// snapshot returns the pending URLs ordered by depth, then URL.
+func (f *frontier) snapshot() []pendingURL {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	pending := make([]pendingURL, 0, len(f.pending))
+	for url, depth := range f.pending {
+		pending = append(pending, pendingURL{URL: url, Depth: depth})
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		if pending[i].Depth != pending[j].Depth {
+			return pending[i].Depth < pending[j].Depth
+		}
+		return pending[i].URL < pending[j].URL
+	})
+	return pending
+}
+
+func (s *Scraper) checkpointPath() string {
+	return filepath.Join(s.config.State.Dir, CheckpointFileName)
+}
+
+// buildCheckpoint captures the crawl state. The visited set is read before
+// the frontier and the frontier before the pages, so every visited URL is
+// either still pending or already has its page captured.
+func (s *Scraper) buildCheckpoint() *checkpoint {
+	var visited []string
+	s.visitedURLs.Range(func(key, _ interface{}) bool {
+		visited = append(visited, key.(string))
+		return true
+	})
+	sort.Strings(visited)
+
+	pending := s.frontier.snapshot()
+	pages := s.aggregator.Pages()
+
+	return &checkpoint{
+		Name:       s.config.Name,
+		UpdatedAt:  time.Now(),
+		Completed:  s.completed.Load(),
+		Visited:    visited,
+		Pending:    pending,
+		Pages:      pages,
+		Navigation: s.aggregator.Navigation(),
+	}
+}
+
+func (s *Scraper) saveCheckpoint() error {
+	cp := s.buildCheckpoint()
+
+	if err := os.MkdirAll(s.config.State.Dir, 0750); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+
+	// Write to a temporary file first so a crash never leaves a truncated checkpoint
+	path := s.checkpointPath()
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace checkpoint: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"visited": len(cp.Visited),
+		"pending": len(cp.Pending),
+		"pages":   len(cp.Pages),
+	}).Debug("Checkpoint saved")
+
+	return nil
+}
+
+func loadCheckpoint(path string) (*checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint %s: %w", path, err)
+	}
+	return &cp, nil
+}
+
+// restoreCheckpoint loads the previous run's state into the scraper and
+// returns the URLs that still need to be visited.
+func (s *Scraper) restoreCheckpoint() ([]pendingURL, error) {
+	cp, err := loadCheckpoint(s.checkpointPath())
+	if errors.Is(err, os.ErrNotExist) {
+		s.logger.WithField("state_dir", s.config.State.Dir).Warn("No checkpoint found, starting a fresh crawl")
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if cp.Name != s.config.Name {
+		return nil, fmt.Errorf("checkpoint in %s belongs to %q, not %q", s.config.State.Dir, cp.Name, s.config.Name)
+	}
+
+	// Pending URLs may have been marked visited before their page was
+	// captured, so they are visited again.
+	pending := make(map[string]bool, len(cp.Pending))
+	for _, p := range cp.Pending {
+		pending[p.URL] = true
+		s.frontier.add(p.URL, p.Depth)
+	}
+	for _, url := range cp.Visited {
+		if !pending[url] {
+			s.visitedURLs.Store(url, true)
+		}
+	}
+
+	s.aggregator.RestorePages(cp.Pages)
+	s.aggregator.AddNavigation(cp.Navigation)
+
+	s.logger.WithFields(logrus.Fields{
+		"visited":   len(cp.Visited),
+		"pending":   len(cp.Pending),
+		"pages":     len(cp.Pages),
+		"completed": cp.Completed,
+		"saved_at":  cp.UpdatedAt.Format(time.RFC3339),
+	}).Info("♻️  Resuming from checkpoint")
+
+	return cp.Pending, nil
+}
+
+// startCheckpointing saves the crawl state periodically. The returned
+// function stops the ticker and writes a final checkpoint.
+func (s *Scraper) startCheckpointing() func() {
+	interval := s.config.State.CheckpointInterval
+	if interval <= 0 {
+		interval = DefaultCheckpointInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.saveCheckpoint(); err != nil {
+					s.logger.WithError(err).Warn("Failed to save checkpoint")
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(stop)
+		wg.Wait()
+
+		if err := s.saveCheckpoint(); err != nil {
+			s.logger.WithError(err).Warn("Failed to save final checkpoint")
+			return
+		}
+		s.logger.WithField("checkpoint", s.checkpointPath()).Info("💾 Crawl state saved")
+	}
+}
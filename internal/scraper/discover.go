@@ -0,0 +1,21 @@
+package scraper
+
+import (
+	"context"
+
+	"github.com/vladkampov/markdocify/internal/report"
+)
+
+// Discover crawls like RunWithContext with the same patterns, domains and
+// depth, but only follows links: pages are neither extracted nor converted
+// and no output is written. It returns every URL seen with the rule that
+// accepted or rejected it, even when the crawl fails or is cut short.
+func (s *Scraper) Discover(ctx context.Context) (*report.Document, error) {
+	s.dryRun = true
+	if s.report == nil {
+		s.report = report.New()
+	}
+
+	err := s.RunWithContext(ctx)
+	return s.report.Document(s.config.Name, s.completed.Load()), err
+}
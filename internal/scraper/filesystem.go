@@ -0,0 +1,219 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vladkampov/markdocify/internal/config"
+)
+
+// filesystemSource serves a local directory as if it were published at
+// base. It is used as the HTTP transport, so pages read from disk go
+// through the same crawl and conversion pipeline as fetched ones.
+type filesystemSource struct {
+	root string
+	base *url.URL
+}
+
+func newFilesystemSource(cfg config.SourceConfig) (*filesystemSource, error) {
+	root, err := filepath.Abs(cfg.Root)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source root: %w", err)
+	}
+
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = config.DefaultFilesystemBaseURL
+	}
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source base URL: %w", err)
+	}
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+	}
+
+	return &filesystemSource{root: root, base: base}, nil
+}
+
+// sameOrigin reports whether u has the scheme and host of the base URL.
+func (f *filesystemSource) sameOrigin(u *url.URL) bool {
+	return u.Scheme == f.base.Scheme && strings.EqualFold(u.Host, f.base.Host)
+}
+
+// contains reports whether urlStr points inside the served directory.
+func (f *filesystemSource) contains(urlStr string) bool {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return false
+	}
+	_, ok := f.filePath(u)
+	return ok
+}
+
+// filePath maps u to a path below the root directory.
+func (f *filesystemSource) filePath(u *url.URL) (string, bool) {
+	if !f.sameOrigin(u) {
+		return "", false
+	}
+
+	rel, ok := strings.CutPrefix(u.Path, f.base.Path)
+	if !ok {
+		if u.Path+"/" != f.base.Path {
+			return "", false
+		}
+		rel = ""
+	}
+
+	// Cleaning a rooted path keeps ".." from leaving the root
+	return filepath.Join(f.root, filepath.FromSlash(path.Clean("/"+rel))), true
+}
+
+// RoundTrip answers requests from the root directory. Directories are
+// served by their index.html, after redirecting to the slash-terminated
+// URL like a web server would, so relative links resolve correctly.
+func (f *filesystemSource) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !f.sameOrigin(req.URL) {
+		return nil, fmt.Errorf("%s is outside the filesystem source %s", req.URL, f.base)
+	}
+
+	name, ok := f.filePath(req.URL)
+	if !ok {
+		return fileResponse(req, http.StatusNotFound, nil), nil
+	}
+
+	info, err := os.Stat(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fileResponse(req, http.StatusNotFound, nil), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		if !strings.HasSuffix(req.URL.Path, "/") {
+			location := *req.URL
+			location.Path += "/"
+			resp := fileResponse(req, http.StatusMovedPermanently, nil)
+			resp.Header.Set("Location", location.String())
+			return resp, nil
+		}
+		name = filepath.Join(name, "index.html")
+	}
+
+	data, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fileResponse(req, http.StatusNotFound, nil), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp := fileResponse(req, http.StatusOK, data)
+	contentType := mime.TypeByExtension(filepath.Ext(name))
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	resp.Header.Set("Content-Type", contentType)
+	return resp, nil
+}
+
+func fileResponse(req *http.Request, status int, body []byte) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        make(http.Header),
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// pageURLs returns the URLs of every HTML file below the root, skipping
+// hidden files and directories.
+func (f *filesystemSource) pageURLs() ([]string, error) {
+	var urls []string
+	err := filepath.WalkDir(f.root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name != f.root && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".html", ".htm":
+		default:
+			return nil
+		}
+
+		rel, err := filepath.Rel(f.root, name)
+		if err != nil {
+			return err
+		}
+		urls = append(urls, f.base.ResolveReference(&url.URL{Path: filepath.ToSlash(rel)}).String())
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s: %w", f.root, err)
+	}
+	return urls, nil
+}
+
+// visitSourceFiles crawls the HTML files of the filesystem source that no
+// link led to, and returns how many were queued.
+func (s *Scraper) visitSourceFiles(ctx context.Context) int {
+	urls, err := s.source.pageURLs()
+	if err != nil {
+		s.logger.WithError(err).Warn("Failed to list source files")
+		return 0
+	}
+
+	var seeds []pendingURL
+	for _, pageURL := range urls {
+		if ctx.Err() != nil {
+			break
+		}
+		pageURL = s.canonicalURL(pageURL)
+		if s.wasVisited(pageURL) {
+			continue
+		}
+		if follow, outcome, reason := s.followDecision(pageURL); !follow {
+			s.trackSkip(pageURL, "", 1, outcome, reason)
+			continue
+		}
+
+		s.trackQueued(pageURL, "", 1, "filesystem source")
+		seeds = append(seeds, pendingURL{URL: pageURL, Depth: 1})
+	}
+	s.crawl(ctx, seeds)
+
+	s.logger.WithFields(logrus.Fields{
+		"root":     s.source.root,
+		"files":    len(urls),
+		"unlinked": len(seeds),
+	}).Info("📁 Read local HTML files")
+
+	return len(seeds)
+}
@@ -0,0 +1,230 @@
+package scraper
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vladkampov/markdocify/internal/config"
+)
+
+// imageExtensions names downloaded images by their content type.
+var imageExtensions = map[string]string{
+	"image/png":     ".png",
+	"image/jpeg":    ".jpg",
+	"image/gif":     ".gif",
+	"image/webp":    ".webp",
+	"image/svg+xml": ".svg",
+	"image/avif":    ".avif",
+	"image/bmp":     ".bmp",
+	"image/x-icon":  ".ico",
+}
+
+// imageStore holds the images fetched for the download and data_uri image
+// modes. Each URL is fetched once, however many pages use it. Downloaded
+// images are named by the hash of their content, so an image served under
+// several URLs is saved once.
+type imageStore struct {
+	mode string
+	// dir is where images are downloaded to
+	dir string
+
+	mu      sync.Mutex
+	entries map[string]*storedImage
+
+	stored atomic.Int64
+	failed atomic.Int64
+}
+
+type storedImage struct {
+	once sync.Once
+	src  string
+	err  error
+}
+
+func newImageStore(cfg *config.Config) *imageStore {
+	return &imageStore{
+		mode:    cfg.Output.Images,
+		dir:     filepath.Join(filepath.Dir(cfg.OutputFile), config.DefaultAssetsDir),
+		entries: make(map[string]*storedImage),
+	}
+}
+
+func (st *imageStore) entry(imageURL string) *storedImage {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	e, ok := st.entries[imageURL]
+	if !ok {
+		e = &storedImage{}
+		st.entries[imageURL] = e
+	}
+	return e
+}
+
+// storeImage is the converter's image fetcher. It returns the src the
+// image at imageURL is written with.
+func (s *Scraper) storeImage(imageURL string) (string, error) {
+	e := s.images.entry(imageURL)
+	e.once.Do(func() {
+		e.src, e.err = s.loadImage(imageURL)
+		if e.err != nil {
+			s.images.failed.Add(1)
+			s.logger.WithFields(logrus.Fields{
+				"url":   imageURL,
+				"error": e.err.Error(),
+			}).Warn("Keeping remote image")
+			return
+		}
+		s.images.stored.Add(1)
+	})
+	return e.src, e.err
+}
+
+// loadImage fetches an image within allowed_domains and max_file_size,
+// then saves or inlines it.
+func (s *Scraper) loadImage(imageURL string) (string, error) {
+	if !s.isAllowedDomain(imageURL) {
+		return "", fmt.Errorf("domain is not allowed")
+	}
+
+	req, err := http.NewRequestWithContext(s.runCtx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", s.getUserAgent())
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	limit := s.config.Security.MaxFileSizeBytes
+	body := io.Reader(resp.Body)
+	if limit > 0 {
+		body = io.LimitReader(resp.Body, limit+1)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read image: %w", err)
+	}
+	if limit > 0 && int64(len(data)) > limit {
+		return "", fmt.Errorf("image exceeds max_file_size (%s)", s.config.Security.MaxFileSize)
+	}
+
+	contentType := imageContentType(resp.Header.Get("Content-Type"), data)
+	if contentType == "" {
+		return "", fmt.Errorf("response is not an image")
+	}
+
+	if s.images.mode == config.ImagesDataURI {
+		return "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+	}
+	return s.images.save(imageURL, contentType, data)
+}
+
+// save writes an image to the assets directory unless an image with the
+// same content is already there, and returns its path relative to the
+// output file.
+func (st *imageStore) save(imageURL, contentType string, data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	name := hex.EncodeToString(sum[:])[:16] + imageExtension(imageURL, contentType)
+
+	file := filepath.Join(st.dir, name)
+	if _, err := os.Stat(file); err != nil {
+		if err := os.MkdirAll(st.dir, 0755); err != nil {
+			return "", fmt.Errorf("failed to create assets directory: %w", err)
+		}
+		// Written under a temporary name, so that a concurrent save of
+		// the same content never exposes a partial file
+		tmp, err := os.CreateTemp(st.dir, ".image-*")
+		if err != nil {
+			return "", fmt.Errorf("failed to save image: %w", err)
+		}
+		_, writeErr := tmp.Write(data)
+		closeErr := tmp.Close()
+		if writeErr == nil {
+			writeErr = closeErr
+		}
+		if writeErr == nil {
+			writeErr = os.Rename(tmp.Name(), file)
+		}
+		if writeErr != nil {
+			os.Remove(tmp.Name())
+			return "", fmt.Errorf("failed to save image: %w", writeErr)
+		}
+	}
+
+	return path.Join(config.DefaultAssetsDir, name), nil
+}
+
+// imageContentType returns the image type of a response, from its header
+// or sniffed from its content, or "" if it is not an image.
+func imageContentType(header string, data []byte) string {
+	if mediaType, _, err := mime.ParseMediaType(header); err == nil && strings.HasPrefix(mediaType, "image/") {
+		return mediaType
+	}
+	if sniffed := http.DetectContentType(data); strings.HasPrefix(sniffed, "image/") {
+		return sniffed
+	}
+	return ""
+}
+
+// imageExtension picks the file extension of a downloaded image, keeping
+// the one in its URL for types without a known extension.
+func imageExtension(imageURL, contentType string) string {
+	if ext, ok := imageExtensions[contentType]; ok {
+		return ext
+	}
+	if u, err := url.Parse(imageURL); err == nil {
+		if ext := strings.ToLower(path.Ext(u.Path)); validExtension(ext) {
+			return ext
+		}
+	}
+	return ""
+}
+
+// validExtension reports whether ext is a plausible file extension.
+func validExtension(ext string) bool {
+	if len(ext) < 2 || len(ext) > 6 {
+		return false
+	}
+	for _, r := range ext[1:] {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Scraper) logImageSummary() {
+	if s.images == nil || s.images.stored.Load()+s.images.failed.Load() == 0 {
+		return
+	}
+
+	fields := logrus.Fields{
+		"mode":   s.images.mode,
+		"images": s.images.stored.Load(),
+		"failed": s.images.failed.Load(),
+	}
+	if s.images.mode == config.ImagesDownload {
+		fields["assets_dir"] = s.images.dir
+	}
+	s.logger.WithFields(fields).Info("🖼️  Stored images")
+}
@@ -0,0 +1,93 @@
+package scraper
+
+import (
+	"github.com/gocolly/colly/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/vladkampov/markdocify/internal/report"
+)
+
+// addConditionalHeaders turns a request for a cached page into a
+// conditional request so unchanged pages come back as 304 Not Modified.
+func (s *Scraper) addConditionalHeaders(r *colly.Request) {
+	if s.cache == nil {
+		return
+	}
+	// The navigation is only read from a full start page
+	if s.config.Selectors.Navigation != "" && s.isStartURL(r.URL.String()) {
+		return
+	}
+
+	entry, ok := s.cache.Lookup(r.URL.String())
+	if !ok {
+		return
+	}
+
+	if entry.ETag != "" {
+		r.Headers.Set("If-None-Match", entry.ETag)
+	}
+	if entry.LastModified != "" {
+		r.Headers.Set("If-Modified-Since", entry.LastModified)
+	}
+}
+
+// handleNotModified reuses the cached markdown and links of a page the
+// server reported as unchanged.
+func (s *Scraper) handleNotModified(r *colly.Response) {
+	currentURL := s.canonicalURL(r.Request.URL.String())
+
+	entry, ok := s.cache.Reuse(currentURL)
+	if !ok {
+		s.logger.WithFields(logrus.Fields{
+			"url":    currentURL,
+			"reason": "not_modified_without_cache_entry",
+		}).Warn("Skipping page")
+		return
+	}
+
+	if _, visited := s.visitedURLs.LoadOrStore(currentURL, true); visited {
+		return
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"url":   currentURL,
+		"depth": r.Request.Depth,
+	}).Info("Page not modified, reusing cached content")
+
+	// Cached links were filtered by an earlier run's rules, so re-check them
+	var links []string
+	for _, link := range entry.Links {
+		if s.shouldFollow(link) && s.isAllowedDomain(link) {
+			links = append(links, link)
+		}
+	}
+	s.results.add(&pageResult{
+		queued:   r.Ctx.Get(ctxQueuedURL),
+		url:      currentURL,
+		title:    entry.Title,
+		markdown: entry.Markdown,
+		depth:    r.Request.Depth,
+		outcome:  report.OutcomeNotModified,
+		pageURL:  r.Request.URL.String(),
+		links:    links,
+	})
+}
+
+// saveCache persists the page cache and logs how this run compared with
+// the previous one. Pages missing from an incomplete run are kept.
+func (s *Scraper) saveCache() {
+	stats := s.cache.Stats()
+	complete := s.completed.Load()
+
+	s.logger.WithFields(logrus.Fields{
+		"fresh":   stats.Fresh,
+		"changed": stats.Changed,
+		"new":     stats.New,
+		"gone":    stats.Gone,
+	}).Info("📦 Incremental scrape summary")
+
+	if err := s.cache.Save(complete); err != nil {
+		s.logger.WithError(err).Warn("Failed to save page cache")
+		return
+	}
+	s.logger.WithField("cache_file", s.cache.Path()).Debug("Page cache saved")
+}
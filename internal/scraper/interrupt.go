@@ -0,0 +1,97 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// contextTransport sends every request under the crawl's context, so
+// cancelling the crawl aborts the requests in flight.
+type contextTransport struct {
+	ctx  context.Context
+	next http.RoundTripper
+}
+
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.ctx.Err(); err != nil {
+		return nil, err
+	}
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return next.RoundTrip(req.WithContext(t.ctx))
+}
+
+// cancelled reports whether the crawl has been cancelled.
+func (s *Scraper) cancelled() bool {
+	return s.runCtx.Err() != nil
+}
+
+// sleep waits for d and reports whether it did, or returns false as soon
+// as the crawl is cancelled.
+func (s *Scraper) sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-s.runCtx.Done():
+		return false
+	}
+}
+
+// unvisitedURLs returns the URLs queued but not crawled when the crawl
+// stopped.
+func (s *Scraper) unvisitedURLs() []string {
+	pending := s.frontier.snapshot()
+	urls := make([]string, len(pending))
+	for i, p := range pending {
+		urls[i] = p.URL
+	}
+	return urls
+}
+
+// finishInterrupted logs why the crawl stopped before it was done and,
+// when output.write_partial is set, writes the pages converted so far,
+// marked as incomplete. It returns the context's error.
+func (s *Scraper) finishInterrupted(ctx context.Context) error {
+	reason := "The crawl was cancelled"
+	if ctx.Err() == context.DeadlineExceeded {
+		reason = "The scraping timeout was reached"
+		s.logger.WithFields(logrus.Fields{
+			"timeout": s.config.Security.ScrapingTimeout.String(),
+			"reason":  "scraping_timeout_exceeded",
+		}).Warn("Scraping timed out - consider increasing scraping_timeout in config")
+	} else {
+		s.logger.WithFields(logrus.Fields{
+			"reason": ctx.Err().Error(),
+		}).Warn("Scraping cancelled")
+	}
+
+	unvisited := s.unvisitedURLs()
+	if !s.config.Output.WritePartial || s.dryRun {
+		return ctx.Err()
+	}
+	if s.aggregator.GetPageCount() == 0 {
+		s.logger.Warn("No pages converted before the crawl stopped, not writing partial output")
+		return ctx.Err()
+	}
+
+	s.aggregator.SetIncomplete(reason, unvisited)
+	if err := s.aggregator.GenerateOutput(); err != nil {
+		s.logger.WithError(err).Error("Failed to write partial output")
+		return ctx.Err()
+	}
+	s.logLinkSummary()
+	s.logger.WithFields(logrus.Fields{
+		"output_file": s.config.OutputFile,
+		"pages":       s.aggregator.GetPageCount(),
+		"unvisited":   len(unvisited),
+	}).Warn("📝 Partial output written")
+
+	return ctx.Err()
+}
@@ -0,0 +1,103 @@
+package scraper
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/vladkampov/markdocify/internal/report"
+)
+
+// abortOversized stops the download of a response whose declared
+// Content-Length is above max_file_size.
+func (s *Scraper) abortOversized(r *colly.Response) {
+	limit := s.config.Security.MaxFileSizeBytes
+	if limit <= 0 {
+		return
+	}
+
+	size, err := strconv.ParseInt(r.Headers.Get("Content-Length"), 10, 64)
+	if err != nil || size <= limit {
+		return
+	}
+
+	s.recordOversized(r.Request.URL.String(), size)
+	r.Request.Abort()
+}
+
+// checkBodySize catches oversized responses sent without a Content-Length.
+// The collector reads at most one byte past the limit, so anything longer
+// than the limit was cut off.
+func (s *Scraper) checkBodySize(r *colly.Response) {
+	limit := s.config.Security.MaxFileSizeBytes
+	if limit <= 0 || int64(len(r.Body)) <= limit {
+		return
+	}
+	s.recordOversized(r.Request.URL.String(), int64(len(r.Body)))
+}
+
+func (s *Scraper) recordOversized(url string, size int64) {
+	if _, seen := s.oversized.LoadOrStore(url, size); seen {
+		return
+	}
+	s.track(url, func(e *report.Entry) {
+		e.Outcome = report.OutcomeTooLarge
+		e.ResponseBytes = int(size)
+	})
+	s.logger.WithFields(logrus.Fields{
+		"url":       url,
+		"size":      size,
+		"max_bytes": s.config.Security.MaxFileSizeBytes,
+		"reason":    "max_file_size_exceeded",
+	}).Warn("Skipping page")
+}
+
+func (s *Scraper) isOversized(url string) bool {
+	_, oversized := s.oversized.Load(url)
+	return oversized
+}
+
+func (s *Scraper) oversizedURLs() []string {
+	var urls []string
+	s.oversized.Range(func(key, _ interface{}) bool {
+		urls = append(urls, key.(string))
+		return true
+	})
+	sort.Strings(urls)
+	return urls
+}
+
+// checkBudget logs once when the output budget stops the crawl.
+func (s *Scraper) checkBudget() {
+	if !s.aggregator.BudgetExceeded() {
+		return
+	}
+	s.budgetOnce.Do(func() {
+		s.logger.WithFields(logrus.Fields{
+			"max_pages":       s.config.Processing.MaxPages,
+			"max_output_size": s.config.Output.MaxOutputSize,
+			"pages":           s.aggregator.GetPageCount(),
+			"output_bytes":    s.aggregator.TotalSize(),
+		}).Warn("⛔ Output budget reached, stopping crawl")
+	})
+}
+
+func (s *Scraper) logLimitsSummary() {
+	oversized := s.oversizedURLs()
+	if len(oversized) == 0 {
+		return
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"skipped_urls": len(oversized),
+		"max_bytes":    s.config.Security.MaxFileSizeBytes,
+	}).Warn("Responses skipped for exceeding max_file_size")
+
+	for _, u := range oversized {
+		s.logger.WithFields(logrus.Fields{
+			"url":    u,
+			"reason": "max_file_size_exceeded",
+		}).Info("Not converted")
+	}
+}
@@ -0,0 +1,120 @@
+package scraper
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/vladkampov/markdocify/internal/aggregator"
+)
+
+// isStartURL reports whether urlStr is one of the configured start URLs.
+func (s *Scraper) isStartURL(urlStr string) bool {
+	_, ok := s.startURLs.Load(urlStr)
+	return ok
+}
+
+// captureNavigation reads the navigation tree of a start page so that the
+// output follows the order chosen by the documentation authors.
+func (s *Scraper) captureNavigation(e *colly.HTMLElement, pageURL string) []*aggregator.NavItem {
+	selector := s.config.Selectors.Navigation
+	if selector == "" || !s.isStartURL(pageURL) {
+		return nil
+	}
+
+	nav := e.DOM.Find(selector).First()
+	if nav.Length() == 0 {
+		s.logger.WithFields(logrus.Fields{
+			"url":      pageURL,
+			"selector": selector,
+		}).Warn("Navigation not found on start page")
+		return nil
+	}
+
+	items := s.parseNavigation(e, nav)
+	s.logger.WithFields(logrus.Fields{
+		"url":     pageURL,
+		"entries": countNavItems(items),
+	}).Info("🧭 Captured navigation")
+	return items
+}
+
+// parseNavigation builds a tree from the nested lists in nav. Navigation
+// without lists is read as a flat sequence of links.
+func (s *Scraper) parseNavigation(e *colly.HTMLElement, nav *goquery.Selection) []*aggregator.NavItem {
+	var items []*aggregator.NavItem
+
+	lists := nav.Find("ul, ol").FilterFunction(func(_ int, list *goquery.Selection) bool {
+		return list.ParentsUntilSelection(nav).Filter("li").Length() == 0
+	})
+	if lists.Length() == 0 {
+		nav.Find("a[href]").Each(func(_ int, a *goquery.Selection) {
+			if item := s.navLink(e, a); item != nil {
+				items = append(items, item)
+			}
+		})
+		return items
+	}
+
+	lists.Each(func(_ int, list *goquery.Selection) {
+		items = append(items, s.parseNavList(e, list)...)
+	})
+	return items
+}
+
+func (s *Scraper) parseNavList(e *colly.HTMLElement, list *goquery.Selection) []*aggregator.NavItem {
+	var items []*aggregator.NavItem
+
+	list.ChildrenFiltered("li").Each(func(_ int, li *goquery.Selection) {
+		// Only links and lists belonging to this item, not to nested ones
+		owned := func(_ int, sel *goquery.Selection) bool {
+			return sel.ParentsUntilSelection(li).Filter("li").Length() == 0
+		}
+
+		item := &aggregator.NavItem{}
+		if link := li.Find("a[href]").FilterFunction(owned).First(); link.Length() > 0 {
+			if parsed := s.navLink(e, link); parsed != nil {
+				item = parsed
+			}
+		}
+		if item.Title == "" {
+			label := li.Clone()
+			label.Find("ul, ol").Remove()
+			item.Title = strings.Join(strings.Fields(label.Text()), " ")
+		}
+
+		li.Find("ul, ol").FilterFunction(owned).Each(func(_ int, nested *goquery.Selection) {
+			item.Children = append(item.Children, s.parseNavList(e, nested)...)
+		})
+
+		if item.Title != "" || item.URL != "" || len(item.Children) > 0 {
+			items = append(items, item)
+		}
+	})
+
+	return items
+}
+
+// navLink turns a navigation link into an item with a canonical URL.
+// Links leaving the allowed domains keep their title but lose the URL.
+func (s *Scraper) navLink(e *colly.HTMLElement, a *goquery.Selection) *aggregator.NavItem {
+	href, _ := a.Attr("href")
+	item := &aggregator.NavItem{Title: strings.Join(strings.Fields(a.Text()), " ")}
+
+	if absolute := e.Request.AbsoluteURL(href); absolute != "" && s.isAllowedDomain(absolute) {
+		item.URL = s.canonicalizer.canonicalize(absolute)
+	}
+	if item.Title == "" && item.URL == "" {
+		return nil
+	}
+	return item
+}
+
+func countNavItems(items []*aggregator.NavItem) int {
+	count := len(items)
+	for _, item := range items {
+		count += countNavItems(item.Children)
+	}
+	return count
+}
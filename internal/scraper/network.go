@@ -0,0 +1,177 @@
+package scraper
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vladkampov/markdocify/internal/config"
+	"github.com/vladkampov/markdocify/internal/warc"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// createTransport returns the transport shared by the collector and the
+// HTTP client: a local source if one is configured and the network
+// otherwise, recorded to a WARC file when warc_file is set, limited per
+// host, with the per-domain request settings applied on top. Every request
+// runs under the run's context, so cancelling the run aborts it.
+func (s *Scraper) createTransport() (http.RoundTripper, error) {
+	var transport http.RoundTripper
+	switch {
+	case s.source != nil:
+		transport = s.source
+	case s.replay != nil:
+		transport = s.replay
+	default:
+		network, err := newNetworkTransport(s.config.Network, s.logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure network: %w", err)
+		}
+		transport = network
+	}
+
+	if s.config.WARCFile != "" {
+		writer, err := warc.Create(s.config.WARCFile, ArchiveSoftware)
+		if err != nil {
+			return nil, err
+		}
+		s.archive = writer
+		transport = &warc.Recorder{
+			Transport:   transport,
+			Writer:      writer,
+			MaxBodySize: s.config.Security.MaxFileSizeBytes,
+		}
+	}
+
+	s.limiter.next = transport
+	transport = s.limiter
+
+	// Above the recorder, so it sees the headers actually sent; it
+	// leaves the credentials among them out of the archive
+	if s.requests != nil {
+		s.requests.next = transport
+		transport = s.requests
+	}
+	return &contextTransport{ctx: s.runCtx, next: transport}, nil
+}
+
+// newNetworkTransport returns a transport with the proxy and TLS settings
+// of cfg, or nil when none are configured so the default transport is
+// kept. Proxy passwords are never logged.
+func newNetworkTransport(cfg config.NetworkConfig, logger *logrus.Logger) (http.RoundTripper, error) {
+	if !cfg.Proxy.IsSet() && len(cfg.CAFiles) == 0 && cfg.ClientCert == "" && cfg.TLSMinVersion == "" {
+		return nil, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if cfg.Proxy.IsSet() {
+		proxy, err := proxyFunc(cfg.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = proxy
+	}
+
+	tlsConfig, err := tlsConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	fields := logrus.Fields{
+		"ca_files":        len(cfg.CAFiles),
+		"client_cert":     cfg.ClientCert != "",
+		"tls_min_version": cfg.TLSMinVersion,
+	}
+	if cfg.Proxy.IsSet() {
+		fields["http_proxy"] = redactedProxy(cfg.Proxy.ForScheme("http"))
+		fields["https_proxy"] = redactedProxy(cfg.Proxy.ForScheme("https"))
+		fields["no_proxy"] = strings.Join(cfg.Proxy.NoProxy, ",")
+	}
+	logger.WithFields(fields).Info("🌐 Using custom network settings")
+
+	return transport, nil
+}
+
+// proxyFunc chooses the proxy per request scheme, honoring no_proxy with
+// the same rules as the NO_PROXY environment variable.
+func proxyFunc(cfg config.ProxyConfig) (func(*http.Request) (*url.URL, error), error) {
+	var user *url.Userinfo
+	if cfg.Username != "" {
+		user = url.User(cfg.Username)
+		if cfg.Password != nil {
+			password, err := cfg.Password.Resolve()
+			if err != nil {
+				return nil, fmt.Errorf("network.proxy.password: %w", err)
+			}
+			user = url.UserPassword(cfg.Username, password)
+		}
+	}
+
+	choose := (&httpproxy.Config{
+		HTTPProxy:  cfg.ForScheme("http"),
+		HTTPSProxy: cfg.ForScheme("https"),
+		NoProxy:    strings.Join(cfg.NoProxy, ","),
+	}).ProxyFunc()
+
+	return func(req *http.Request) (*url.URL, error) {
+		proxy, err := choose(req.URL)
+		if proxy == nil || err != nil {
+			return proxy, err
+		}
+		if user != nil {
+			withUser := *proxy
+			withUser.User = user
+			proxy = &withUser
+		}
+		return proxy, nil
+	}, nil
+}
+
+func tlsConfig(cfg config.NetworkConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.TLSMinVersion != "" {
+		tlsConfig.MinVersion = config.TLSVersions[cfg.TLSMinVersion]
+	}
+
+	if len(cfg.CAFiles) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		for _, file := range cfg.CAFiles {
+			data, err := os.ReadFile(file) // #nosec G304 -- path comes from the user's config
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CA file: %w", err)
+			}
+			if !pool.AppendCertsFromPEM(data) {
+				return nil, fmt.Errorf("no PEM certificates found in CA file %s", file)
+			}
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.ClientCert != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCert, cfg.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+func redactedProxy(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,252 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/vladkampov/markdocify/internal/aggregator"
+	"github.com/vladkampov/markdocify/internal/report"
+)
+
+// ctxQueuedURL is the request context key holding the URL a request was
+// queued under, which redirects and canonicalization may change.
+const ctxQueuedURL = "queued_url"
+
+// ctxDepth is the request context key holding the crawl depth a request
+// was queued at.
+const ctxDepth = "depth"
+
+// workerPool converts pages off the fetch goroutines, so a heavy page does
+// not hold up fetching. submit blocks while every worker is busy, which
+// slows fetching down when conversion cannot keep up.
+type workerPool struct {
+	slots chan struct{}
+	wg    sync.WaitGroup
+}
+
+func newWorkerPool(workers int) *workerPool {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	return &workerPool{slots: make(chan struct{}, workers)}
+}
+
+func (p *workerPool) submit(job func()) {
+	p.wg.Add(1)
+	p.slots <- struct{}{}
+	go func() {
+		defer func() {
+			<-p.slots
+			p.wg.Done()
+		}()
+		job()
+	}()
+}
+
+// wait blocks until every submitted job has finished.
+func (p *workerPool) wait() {
+	p.wg.Wait()
+}
+
+// pageResult is a page handled while crawling a depth level. Results are
+// committed to the aggregator once the whole level is done.
+type pageResult struct {
+	queued      string
+	url         string
+	title       string
+	markdown    string
+	depth       int
+	outcome     report.Outcome
+	convertTime time.Duration
+	// links are followed from pageURL, the URL the page was fetched at
+	pageURL string
+	links   []string
+	// navigation is the tree captured from a start page
+	navigation []*aggregator.NavItem
+}
+
+// levelResults collects the results of the level being crawled.
+type levelResults struct {
+	mu      sync.Mutex
+	results []*pageResult
+}
+
+func (l *levelResults) add(r *pageResult) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.results = append(l.results, r)
+}
+
+func (l *levelResults) drain() []*pageResult {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	results := l.results
+	l.results = nil
+	return results
+}
+
+// crawl visits seeds and the pages reachable from them breadth-first, one
+// depth level at a time. The pages of a level are fetched and converted
+// concurrently, then committed in the order they were queued: seeds in the
+// order given, links in the order they appear on the pages of the level
+// before. Every page thus gets its shortest link depth, and duplicate
+// detection and the output budget see the pages in the same order however
+// the requests complete.
+func (s *Scraper) crawl(ctx context.Context, seeds []pendingURL) {
+	levels := make(map[int][]string)
+	for _, seed := range seeds {
+		s.frontier.add(seed.URL, seed.Depth)
+		levels[seed.Depth] = append(levels[seed.Depth], seed.URL)
+	}
+
+	for depth := 1; len(levels) > 0; depth++ {
+		urls := levels[depth]
+		delete(levels, depth)
+		if ctx.Err() != nil {
+			return
+		}
+		if len(urls) == 0 {
+			continue
+		}
+
+		order := make(map[string]int, len(urls))
+		var queue []string
+		for _, u := range urls {
+			if _, seen := order[u]; !seen {
+				order[u] = len(order)
+				queue = append(queue, u)
+			}
+		}
+
+		// Near the max_pages budget a level is queued in batches no larger
+		// than the pages still allowed, so no page past it is fetched
+		for len(queue) > 0 && ctx.Err() == nil {
+			batch := queue
+			if n := s.pagesLeft(); n < len(batch) {
+				batch = queue[:n]
+			}
+			queue = queue[len(batch):]
+
+			for _, u := range batch {
+				if ctx.Err() != nil {
+					break
+				}
+				if s.wasVisited(u) {
+					continue
+				}
+				s.frontier.add(u, depth)
+				if err := s.visitAtDepth(u, depth); err != nil && err != colly.ErrAlreadyVisited {
+					s.visitErrors.Store(u, err)
+					s.logger.WithError(err).Warnf("Failed to visit URL: %s", u)
+				}
+			}
+
+			s.collector.Wait()
+			s.workers.wait()
+
+			// Pages converted before a cancellation are still committed so
+			// partial output has them; the ones cut off stay pending
+			if next := s.commitLevel(order); len(next) > 0 {
+				levels[depth+1] = append(levels[depth+1], next...)
+			}
+			for _, u := range batch {
+				if ctx.Err() == nil || s.wasVisited(u) || s.visitError(u) != nil {
+					s.frontier.done(u)
+				}
+			}
+		}
+
+		s.logger.WithFields(logrus.Fields{
+			"depth": depth,
+			"urls":  len(order),
+		}).Debug("Crawled depth level")
+	}
+}
+
+// pagesLeft returns how many more pages max_pages allows, but at least one
+// so that the page going over it is seen and stops the crawl.
+func (s *Scraper) pagesLeft() int {
+	maxPages := s.config.Processing.MaxPages
+	if maxPages <= 0 {
+		return math.MaxInt
+	}
+	if left := maxPages - s.aggregator.GetPageCount(); left > 1 {
+		return left
+	}
+	return 1
+}
+
+// commitLevel adds the pages of the finished level to the aggregator in
+// the order of the URLs they were queued under and returns the links to
+// visit at the next level.
+func (s *Scraper) commitLevel(order map[string]int) []string {
+	results := s.results.drain()
+	sort.SliceStable(results, func(i, j int) bool {
+		ri, iok := order[results[i].queued]
+		rj, jok := order[results[j].queued]
+		if iok != jok {
+			return iok
+		}
+		if ri != rj {
+			return ri < rj
+		}
+		return results[i].url < results[j].url
+	})
+
+	var next []string
+	for _, r := range results {
+		if len(r.navigation) > 0 {
+			s.aggregator.AddNavigation(r.navigation)
+		}
+		if r.outcome == "" {
+			continue
+		}
+
+		if s.dryRun {
+			s.trackOutcome(r.url, report.OutcomeDiscovered, "")
+			s.reportProgress()
+		} else {
+			added := s.aggregator.AddPage(r.url, r.title, r.markdown, r.depth)
+			s.trackAdded(r.url, added, r.outcome, len(r.markdown), r.convertTime)
+			s.reportProgress()
+			s.checkBudget()
+		}
+
+		next = append(next, s.followLinksFrom(r.pageURL, r.depth, r.links)...)
+	}
+
+	return next
+}
+
+// visitError returns why url could not be crawled, or nil if it was.
+func (s *Scraper) visitError(url string) error {
+	if s.wasVisited(url) {
+		return nil
+	}
+	if s.isOversized(url) {
+		return fmt.Errorf("response exceeds max_file_size (%s)", s.config.Security.MaxFileSize)
+	}
+	if f, failed := s.failures.Load(url); failed {
+		return fmt.Errorf("failed after %d attempts: %s", f.(failure).Attempts, f.(failure).Error)
+	}
+	if err, failed := s.visitErrors.Load(url); failed {
+		return err.(error)
+	}
+	return nil
+}
+
+// visitAtDepth queues a URL with an explicit crawl depth, which colly's
+// Visit does not allow. The depth is set on the request in OnRequest.
+func (s *Scraper) visitAtDepth(url string, depth int) error {
+	ctx := colly.NewContext()
+	ctx.Put(ctxQueuedURL, url)
+	ctx.Put(ctxDepth, depth)
+	return s.collector.Request("GET", url, nil, ctx, nil)
+}
@@ -0,0 +1,92 @@
+package scraper
+
+import (
+	"time"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/vladkampov/markdocify/internal/aggregator"
+	"github.com/vladkampov/markdocify/internal/report"
+)
+
+// track updates the crawl report entry for url when a report is enabled.
+func (s *Scraper) track(url string, fn func(e *report.Entry)) {
+	if s.report == nil {
+		return
+	}
+	s.report.Update(url, fn)
+}
+
+// trackOutcome sets the final outcome of url.
+func (s *Scraper) trackOutcome(url string, outcome report.Outcome, reason string) {
+	s.track(url, func(e *report.Entry) {
+		e.Outcome = outcome
+		e.Reason = reason
+	})
+}
+
+// trackSkip records a URL that was rejected before being fetched.
+func (s *Scraper) trackSkip(url, parent string, depth int, outcome report.Outcome, reason string) {
+	if s.report == nil {
+		return
+	}
+	s.report.Skip(url, parent, depth, outcome, reason)
+}
+
+// trackQueued records where a URL to be fetched was found and the rule
+// that accepted it.
+func (s *Scraper) trackQueued(url, parent string, depth int, rule string) {
+	s.track(url, func(e *report.Entry) {
+		if e.Parent == "" {
+			e.Parent = parent
+		}
+		if e.Depth == 0 {
+			e.Depth = depth
+		}
+		if e.Rule == "" {
+			e.Rule = rule
+		}
+	})
+}
+
+func (s *Scraper) trackRequest(r *colly.Request) {
+	now := time.Now()
+	s.track(r.URL.String(), func(e *report.Entry) {
+		e.Depth = r.Depth
+		e.Attempts++
+		e.FetchedAt = &now
+	})
+}
+
+func (s *Scraper) trackResponse(r *colly.Response) {
+	s.track(r.Request.URL.String(), func(e *report.Entry) {
+		e.StatusCode = r.StatusCode
+		e.ResponseBytes = len(r.Body)
+		if e.FetchedAt != nil {
+			e.FetchMS = time.Since(*e.FetchedAt).Milliseconds()
+		}
+	})
+}
+
+// trackAdded records what the aggregator did with a converted page.
+func (s *Scraper) trackAdded(url string, result aggregator.AddResult, outcome report.Outcome, markdownBytes int, convert time.Duration) {
+	s.track(url, func(e *report.Entry) {
+		e.MarkdownBytes = markdownBytes
+		e.ConvertMS = convert.Milliseconds()
+		switch result {
+		case aggregator.PageDuplicate:
+			e.Outcome = report.OutcomeDuplicate
+		case aggregator.PageOverBudget:
+			e.Outcome = report.OutcomeOverBudget
+		default:
+			e.Outcome = outcome
+		}
+	})
+}
+
+func (s *Scraper) writeReport() {
+	if err := s.report.Write(s.config.ReportFile, s.config.Name, s.completed.Load()); err != nil {
+		s.logger.WithError(err).Warn("Failed to write crawl report")
+		return
+	}
+	s.logger.WithField("report_file", s.config.ReportFile).Info("🧾 Crawl report written")
+}
@@ -0,0 +1,190 @@
+package scraper
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vladkampov/markdocify/internal/config"
+)
+
+// requestSettings adds the configured per-domain headers and credentials to
+// outgoing requests. It wraps the transport rather than the collector, so
+// robots.txt and sitemap requests and every redirect hop are covered, and
+// credentials are never carried over to another domain.
+type requestSettings struct {
+	domains []domainSettings
+	next    http.RoundTripper
+}
+
+type domainSettings struct {
+	rule   config.DomainRequestConfig
+	header http.Header
+}
+
+// newRequestSettings resolves the secrets of cfg. Errors and log lines name
+// the variables and files secrets come from, never their values. Requests
+// go through next, or the default transport if next is nil.
+func newRequestSettings(cfg config.RequestsConfig, logger *logrus.Logger) (*requestSettings, error) {
+	r := &requestSettings{}
+	for i, rule := range cfg.Domains {
+		header := make(http.Header)
+		for name, value := range rule.Headers {
+			header.Set(name, value)
+		}
+
+		auth := "none"
+		switch {
+		case rule.BearerToken != nil:
+			token, err := rule.BearerToken.Resolve()
+			if err != nil {
+				return nil, fmt.Errorf("requests.domains[%d].bearer_token: %w", i, err)
+			}
+			header.Set("Authorization", "Bearer "+token)
+			auth = "bearer"
+		case rule.BasicAuth != nil:
+			password, err := rule.BasicAuth.Password.Resolve()
+			if err != nil {
+				return nil, fmt.Errorf("requests.domains[%d].basic_auth.password: %w", i, err)
+			}
+			credentials := base64.StdEncoding.EncodeToString([]byte(rule.BasicAuth.Username + ":" + password))
+			header.Set("Authorization", "Basic "+credentials)
+			auth = "basic"
+		}
+
+		names := make([]string, 0, len(rule.Headers))
+		for name := range rule.Headers {
+			names = append(names, http.CanonicalHeaderKey(name))
+		}
+		sort.Strings(names)
+		logger.WithFields(logrus.Fields{
+			"domain":  rule.Domain,
+			"headers": names,
+			"auth":    auth,
+		}).Debug("Request settings for domain")
+
+		r.domains = append(r.domains, domainSettings{rule: rule, header: header})
+	}
+	return r, nil
+}
+
+// headerFor returns the headers for host, or nil if no domain matches.
+func (r *requestSettings) headerFor(host string) http.Header {
+	for _, d := range r.domains {
+		if d.rule.Matches(host) {
+			return d.header
+		}
+	}
+	return nil
+}
+
+func (r *requestSettings) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := r.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	header := r.headerFor(req.URL.Hostname())
+	if header == nil {
+		return next.RoundTrip(req)
+	}
+
+	// A RoundTripper must not modify the caller's request
+	out := req.Clone(req.Context())
+	for name, values := range header {
+		out.Header[name] = values
+	}
+	return next.RoundTrip(out)
+}
+
+// loadCookieJar reads a Netscape cookies.txt file into a cookie jar, which
+// only sends each cookie to the domain and path it was set for. Expired
+// cookies are dropped.
+func loadCookieJar(path string) (*cookiejar.Jar, int, error) {
+	file, err := os.Open(path) // #nosec G304 -- path comes from the user's config
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open cookies file: %w", err)
+	}
+	defer file.Close()
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	count := 0
+	now := time.Now()
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		cookie, u, err := parseCookieLine(scanner.Text())
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+		if cookie == nil || !cookie.Expires.IsZero() && cookie.Expires.Before(now) {
+			continue
+		}
+		jar.SetCookies(u, []*http.Cookie{cookie})
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read cookies file: %w", err)
+	}
+	return jar, count, nil
+}
+
+// parseCookieLine parses a cookies.txt line: domain, include-subdomains
+// flag, path, secure flag, expiry, name and value, separated by tabs.
+// Comments and blank lines yield a nil cookie. Errors never include the
+// cookie's value.
+func parseCookieLine(line string) (*http.Cookie, *url.URL, error) {
+	httpOnly := false
+	if rest, ok := strings.CutPrefix(line, "#HttpOnly_"); ok {
+		line = rest
+		httpOnly = true
+	}
+	if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+		return nil, nil, nil
+	}
+
+	fields := strings.Split(strings.TrimRight(line, "\r"), "\t")
+	if len(fields) != 7 {
+		return nil, nil, fmt.Errorf("expected 7 tab-separated fields, got %d", len(fields))
+	}
+
+	domain := strings.TrimPrefix(fields[0], ".")
+	if domain == "" {
+		return nil, nil, fmt.Errorf("cookie has no domain")
+	}
+	secure := strings.EqualFold(fields[3], "TRUE")
+	cookie := &http.Cookie{
+		Name:     fields[5],
+		Value:    fields[6],
+		Path:     fields[2],
+		Secure:   secure,
+		HttpOnly: httpOnly,
+	}
+	// Host-only cookies have no Domain attribute
+	if strings.EqualFold(fields[1], "TRUE") {
+		cookie.Domain = domain
+	}
+	if expires, err := strconv.ParseInt(fields[4], 10, 64); err != nil {
+		return nil, nil, fmt.Errorf("invalid expiry for cookie %s", cookie.Name)
+	} else if expires > 0 {
+		cookie.Expires = time.Unix(expires, 0)
+	}
+
+	scheme := "http"
+	if secure {
+		scheme = "https"
+	}
+	return cookie, &url.URL{Scheme: scheme, Host: domain, Path: cookie.Path}, nil
+}
@@ -0,0 +1,212 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/vladkampov/markdocify/internal/config"
+	"github.com/vladkampov/markdocify/internal/report"
+)
+
+// retryPolicy decides which failed requests are retried and how long to
+// wait before each attempt.
+type retryPolicy struct {
+	maxAttempts   int
+	backoffBase   time.Duration
+	backoffMax    time.Duration
+	statusCodes   map[int]bool
+	networkErrors map[string]bool
+
+	mu       sync.Mutex
+	attempts map[string]int
+}
+
+// failure describes a URL that could not be fetched.
+type failure struct {
+	URL        string
+	StatusCode int
+	Error      string
+	Attempts   int
+}
+
+func newRetryPolicy(cfg config.RetryConfig) *retryPolicy {
+	p := &retryPolicy{
+		maxAttempts:   cfg.MaxAttempts,
+		backoffBase:   cfg.BackoffBase,
+		backoffMax:    cfg.BackoffMax,
+		statusCodes:   make(map[int]bool),
+		networkErrors: make(map[string]bool),
+		attempts:      make(map[string]int),
+	}
+
+	statusCodes := cfg.StatusCodes
+	if statusCodes == nil {
+		statusCodes = config.DefaultRetryStatusCodes
+	}
+	for _, code := range statusCodes {
+		p.statusCodes[code] = true
+	}
+
+	networkErrors := cfg.NetworkErrors
+	if networkErrors == nil {
+		networkErrors = config.RetryNetworkErrorKinds
+	}
+	for _, kind := range networkErrors {
+		p.networkErrors[kind] = true
+	}
+
+	return p
+}
+
+// next records a failed attempt for url. It returns the number of attempts
+// made so far and whether another one is allowed.
+func (p *retryPolicy) next(url string) (int, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.attempts[url]++
+	return p.attempts[url], p.attempts[url] < p.maxAttempts
+}
+
+// backoff returns the wait before the attempt following attempt.
+func (p *retryPolicy) backoff(attempt int) time.Duration {
+	delay := p.backoffBase
+	for i := 1; i < attempt && delay < p.backoffMax; i++ {
+		delay *= 2
+	}
+	if delay > p.backoffMax {
+		delay = p.backoffMax
+	}
+	return delay
+}
+
+// retryable reports whether a request that failed with statusCode and err
+// should be tried again.
+func (p *retryPolicy) retryable(statusCode int, err error) bool {
+	if statusCode != 0 {
+		return p.statusCodes[statusCode]
+	}
+	kind := networkErrorKind(err)
+	return kind != "" && p.networkErrors[kind]
+}
+
+// networkErrorKind classifies transport errors into the kinds accepted by
+// retry.network_errors.
+func networkErrorKind(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var netErr net.Error
+	var dnsErr *net.DNSError
+	switch {
+	case errors.As(err, &dnsErr):
+		// Unknown hosts will not appear on retry
+		if dnsErr.IsNotFound {
+			return ""
+		}
+		return config.NetworkErrorDNS
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		return config.NetworkErrorTimeout
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return config.NetworkErrorTimeout
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return config.NetworkErrorConnectionRefused
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE):
+		return config.NetworkErrorConnectionReset
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return config.NetworkErrorEOF
+	}
+	return ""
+}
+
+// handleFailure retries a failed request when the policy allows it and
+// otherwise records the URL as permanently failed.
+func (s *Scraper) handleFailure(r *colly.Response, err error) {
+	urlStr := r.Request.URL.String()
+
+	attempt, more := s.retry.next(urlStr)
+	if !more || !s.retry.retryable(r.StatusCode, err) {
+		s.recordFailure(urlStr, r.StatusCode, err, attempt)
+		return
+	}
+
+	backoff := s.retry.backoff(attempt)
+	s.logger.WithFields(logrus.Fields{
+		"url":     urlStr,
+		"status":  r.StatusCode,
+		"attempt": attempt,
+		"backoff": backoff.String(),
+		"error":   err.Error(),
+	}).Info("Retrying request")
+	if !s.sleep(backoff) {
+		return
+	}
+
+	// A failing retry is reported through OnError again
+	if retryErr := r.Request.Retry(); retryErr != nil {
+		s.logger.WithError(retryErr).Debugf("Retry of %s failed", urlStr)
+	}
+}
+
+func (s *Scraper) recordFailure(url string, statusCode int, err error, attempts int) {
+	s.failures.Store(url, failure{
+		URL:        url,
+		StatusCode: statusCode,
+		Error:      err.Error(),
+		Attempts:   attempts,
+	})
+	s.track(url, func(e *report.Entry) {
+		e.Outcome = report.OutcomeHTTPError
+		e.StatusCode = statusCode
+		e.Error = err.Error()
+	})
+	s.logger.WithFields(logrus.Fields{
+		"url":      url,
+		"status":   statusCode,
+		"attempts": attempts,
+		"error":    err.Error(),
+	}).Warn("Failed to fetch page")
+}
+
+// failedURLs returns the permanently failed URLs, sorted.
+func (s *Scraper) failedURLs() []failure {
+	var failures []failure
+	s.failures.Range(func(_, value interface{}) bool {
+		failures = append(failures, value.(failure))
+		return true
+	})
+	sort.Slice(failures, func(i, j int) bool {
+		return failures[i].URL < failures[j].URL
+	})
+	return failures
+}
+
+func (s *Scraper) logFailureSummary() {
+	failures := s.failedURLs()
+	if len(failures) == 0 {
+		return
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"failed_urls": len(failures),
+	}).Warn("❌ Some pages could not be fetched")
+
+	for _, f := range failures {
+		s.logger.WithFields(logrus.Fields{
+			"url":      f.URL,
+			"status":   f.StatusCode,
+			"attempts": f.Attempts,
+			"error":    f.Error,
+		}).Warn("Permanently failed")
+	}
+}
@@ -0,0 +1,109 @@
+package scraper
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/temoto/robotstxt"
+)
+
+// robotsCache fetches robots.txt once per host and keeps the parsed rules
+// for the lifetime of a run.
+type robotsCache struct {
+	client    *http.Client
+	userAgent string
+
+	mu    sync.Mutex
+	hosts map[string]*robotsEntry
+
+	// onLoad is called once for every host right after its robots.txt has
+	// been fetched, before any page of that host is requested.
+	onLoad func(host string, data *robotstxt.RobotsData)
+}
+
+func newRobotsCache(client *http.Client, userAgent string) *robotsCache {
+	return &robotsCache{
+		client:    client,
+		userAgent: userAgent,
+		hosts:     make(map[string]*robotsEntry),
+	}
+}
+
+// robotsEntry holds the rules of one host, fetched once however many
+// requests wait for them.
+type robotsEntry struct {
+	once sync.Once
+	data *robotstxt.RobotsData
+}
+
+func (r *robotsCache) entry(host string) *robotsEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.hosts[host]
+	if !ok {
+		e = &robotsEntry{}
+		r.hosts[host] = e
+	}
+	return e
+}
+
+// get returns the robots.txt rules for the host of u, fetching them on first use.
+// Hosts whose robots.txt cannot be fetched are treated as allowing everything;
+// the error is returned only to the caller that fetched it. Requests to a
+// host wait for its robots.txt, but never for another host's.
+func (r *robotsCache) get(u *url.URL) (*robotstxt.RobotsData, error) {
+	e := r.entry(u.Host)
+
+	var err error
+	e.once.Do(func() {
+		data, fetchErr := r.fetch(u)
+		if fetchErr != nil {
+			data, _ = robotstxt.FromStatusAndBytes(http.StatusNotFound, nil)
+			err = fetchErr
+		}
+		e.data = data
+
+		if r.onLoad != nil {
+			r.onLoad(u.Host, data)
+		}
+	})
+
+	return e.data, err
+}
+
+func (r *robotsCache) fetch(u *url.URL) (*robotstxt.RobotsData, error) {
+	robotsURL := u.Scheme + "://" + u.Host + "/robots.txt"
+
+	req, err := http.NewRequest(http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build robots.txt request: %w", err)
+	}
+	req.Header.Set("User-Agent", r.userAgent)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", robotsURL, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := robotstxt.FromResponse(resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", robotsURL, err)
+	}
+
+	return data, nil
+}
+
+// robotsPath returns the part of u that robots.txt rules are matched against.
+func robotsPath(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return path
+}
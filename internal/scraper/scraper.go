@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"net/http"
 	"net/url"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -13,34 +15,95 @@ import (
 
 	"github.com/gocolly/colly/v2"
 	"github.com/sirupsen/logrus"
+	"github.com/temoto/robotstxt"
+	"github.com/vladkampov/markdocify/internal/cache"
 	"github.com/vladkampov/markdocify/internal/config"
 	"github.com/vladkampov/markdocify/internal/converter"
 	"github.com/vladkampov/markdocify/internal/aggregator"
+	"github.com/vladkampov/markdocify/internal/report"
+	"github.com/vladkampov/markdocify/internal/warc"
 	"github.com/vladkampov/markdocify/internal/types"
 )
 
+// skipRule excludes URLs belonging to a skip category.
+type skipRule struct {
+	category string
+	re       *regexp.Regexp
+}
+
 type Scraper struct {
 	config    *config.Config
 	collector *colly.Collector
+	// httpClient is used for requests made outside the collector,
+	// such as robots.txt and sitemaps.
+	httpClient *http.Client
 	converter *converter.Converter
 	aggregator *aggregator.Aggregator
 	
 	followPatterns []*regexp.Regexp
 	ignorePatterns []*regexp.Regexp
+	skipRules      []skipRule
+	skipLogged     sync.Map
+	titles         *titleCleaner
 	
 	visitedURLs sync.Map
 	// mu was removed - no longer needed with atomic operations
 	pageCount   int64 // Use atomic operations
 	
-	logger *logrus.Logger
-}
+	canonicalizer *canonicalizer
+	// startURLs holds the canonical start URLs
+	startURLs sync.Map
+	
+	limiter    *hostLimiter
+	throttle   *hostThrottle
+	retry      *retryPolicy
+	failures   sync.Map
+	
+	robots        *robotsCache
+	robotsSkipped sync.Map
+	
+	oversized  sync.Map
+	budgetOnce sync.Once
+	
+	frontier  *frontier
+	completed atomic.Bool
 
-const (
-	DefaultMaxRetries = 3
-	DefaultBackoffBase = 1 * time.Second
-	MaxBackoffDelay = 30 * time.Second
-)
+	// workers converts pages, results holds those of the depth level
+	// being crawled until it is committed
+	workers     *workerPool
+	results     levelResults
+	visitErrors sync.Map
+
+	// dryRun only follows links, without extracting or converting pages
+	dryRun bool
+
+	// runCtx is cancelled when the run is, aborting requests in flight
+	runCtx    context.Context
+	cancelRun context.CancelFunc
+	
+	cache *cache.Cache
+	
+	// source serves pages from a local directory in offline mode
+	source *filesystemSource
+	// replay serves pages from a WARC file, archive records to one
+	replay    *warc.Archive
+	archive   *warc.Writer
+	transport http.RoundTripper
+
+	// requests adds per-domain headers and credentials, cookies holds
+	// the cookies loaded from requests.cookies_file
+	requests *requestSettings
+	cookies  http.CookieJar
+	
+	// images stores the images pages use in the download and data_uri
+	// image modes
+	images *imageStore
 
+	// report collects per-URL outcomes when report_file is set
+	report *report.Report
+	
+	logger *logrus.Logger
+}
 
 func New(cfg *config.Config) (*Scraper, error) {
 	logger := logrus.New()
@@ -51,14 +114,78 @@ func New(cfg *config.Config) (*Scraper, error) {
 	logger.SetLevel(level)
 
 	s := &Scraper{
-		config: cfg,
-		logger: logger,
+		config:   cfg,
+		logger:   logger,
+		frontier: newFrontier(),
+		workers:  newWorkerPool(cfg.Processing.Workers),
 	}
 
 	if err := s.compilePatterns(); err != nil {
 		return nil, fmt.Errorf("failed to compile patterns: %w", err)
 	}
 
+	s.titles, err = newTitleCleaner(cfg.TitleRules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile title rules: %w", err)
+	}
+
+	if cfg.Source.IsFilesystem() {
+		s.source, err = newFilesystemSource(cfg.Source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open filesystem source: %w", err)
+		}
+	}
+	if cfg.Source.IsWARC() {
+		if err := s.openReplay(); err != nil {
+			return nil, fmt.Errorf("failed to open WARC source: %w", err)
+		}
+	}
+	if len(cfg.Requests.Domains) > 0 {
+		s.requests, err = newRequestSettings(cfg.Requests, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load request settings: %w", err)
+		}
+	}
+	if cfg.Requests.CookiesFile != "" {
+		jar, count, err := loadCookieJar(cfg.Requests.CookiesFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load cookies: %w", err)
+		}
+		s.cookies = jar
+		logger.WithFields(logrus.Fields{
+			"cookies_file": cfg.Requests.CookiesFile,
+			"cookies":      count,
+		}).Info("🍪 Loaded cookies")
+	}
+	s.runCtx, s.cancelRun = context.WithCancel(context.Background())
+	s.limiter = newHostLimiter()
+	s.transport, err = s.createTransport()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transport: %w", err)
+	}
+
+	s.canonicalizer = newCanonicalizer(cfg.Canonicalize)
+	s.throttle = newHostThrottle(s.baseDelay(), cfg.Throttle.MaxDelay)
+	s.retry = newRetryPolicy(cfg.Retry)
+	s.httpClient = &http.Client{Timeout: cfg.Security.RequestTimeout, Transport: s.transport}
+	if s.cookies != nil {
+		s.httpClient.Jar = s.cookies
+	}
+	s.robots = newRobotsCache(s.httpClient, s.getUserAgent())
+	s.robots.onLoad = s.applyCrawlDelay
+
+	if cfg.Cache.Dir != "" {
+		pageCache, err := cache.Open(cfg.Cache.Dir, cfg.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open page cache: %w", err)
+		}
+		s.cache = pageCache
+	}
+
+	if cfg.ReportFile != "" {
+		s.report = report.New()
+	}
+
 	s.collector = s.createCollector()
 	
 	converter, err := converter.New(cfg)
@@ -66,12 +193,20 @@ func New(cfg *config.Config) (*Scraper, error) {
 		return nil, fmt.Errorf("failed to create converter: %w", err)
 	}
 	s.converter = converter
+	if cfg.Output.Images == config.ImagesDownload || cfg.Output.Images == config.ImagesDataURI {
+		s.images = newImageStore(cfg)
+		s.converter.SetImageFetcher(s.storeImage)
+	}
 
 	aggregator, err := aggregator.New(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create aggregator: %w", err)
 	}
 	s.aggregator = aggregator
+	s.aggregator.SetURLCanonicalizer(s.canonicalizer.canonicalize)
+	if date := s.archiveDate(); !date.IsZero() {
+		s.aggregator.SetGeneratedAt(date)
+	}
 
 	return s, nil
 }
@@ -79,37 +214,175 @@ func New(cfg *config.Config) (*Scraper, error) {
 func (s *Scraper) createCollector() *colly.Collector {
 	c := colly.NewCollector(
 		colly.UserAgent(s.getUserAgent()),
+		colly.Async(true),
 	)
 
 	// Debug logging is handled in OnRequest callback below
 
-	if err := c.Limit(&colly.LimitRule{
-		DomainGlob:  "*",
-		Parallelism: s.config.Processing.Concurrency,
-		Delay:       time.Duration(s.config.Processing.Delay * float64(time.Second)),
-	}); err != nil {
-		s.logger.WithError(err).Warn("Failed to set rate limit")
-	}
+	// Rate limits are registered per host on its first request (see
+	// waitForHost), so each host is limited and throttled on its own and
+	// at most processing.concurrency requests run against it at a time.
 
 	c.SetRequestTimeout(s.config.Security.RequestTimeout)
+	c.WithTransport(s.transport)
+	if s.cookies != nil {
+		c.SetCookieJar(s.cookies)
+	}
+
+	// Read one byte past max_file_size so oversized bodies can be told apart
+	// from ones that fit exactly.
+	if limit := s.config.Security.MaxFileSizeBytes; limit > 0 && limit < math.MaxInt {
+		c.MaxBodySize = int(limit) + 1
+	}
 
 	c.OnRequest(func(r *colly.Request) {
+		if depth, ok := r.Ctx.GetAny(ctxDepth).(int); ok {
+			r.Depth = depth
+		}
+		if s.cancelled() {
+			r.Abort()
+			return
+		}
+		if s.aggregator.BudgetExceeded() {
+			s.trackOutcome(r.URL.String(), report.OutcomeOverBudget, "")
+			r.Abort()
+			return
+		}
+		s.waitForHost(r)
+		if s.cancelled() {
+			r.Abort()
+			return
+		}
+		s.trackRequest(r)
 		s.logger.WithField("url", r.URL.String()).Debug("Visiting URL")
+		s.addConditionalHeaders(r)
 	})
 
+	c.OnResponseHeaders(s.abortOversized)
+
 	c.OnHTML("html", s.handleHTML)
 
 	c.OnError(func(r *colly.Response, err error) {
-		s.logger.Warnf("Error scraping %s: %v", r.Request.URL, err)
+		// Already logged by abortOversized
+		if err == colly.ErrAbortedAfterHeaders {
+			return
+		}
+		// Cut off by cancellation, the URL stays pending
+		if s.cancelled() {
+			return
+		}
+		s.trackResponse(r)
+		// Colly reports 304 Not Modified as an error
+		if r.StatusCode == http.StatusNotModified && s.cache != nil {
+			s.handleNotModified(r)
+			return
+		}
+		if s.handleThrottled(r, err) {
+			return
+		}
+		s.handleFailure(r, err)
 	})
 
 	c.OnResponse(func(r *colly.Response) {
 		s.logger.Debugf("Response from %s: %d bytes", r.Request.URL, len(r.Body))
+		s.trackResponse(r)
+		s.checkBodySize(r)
+		s.throttle.relax(r.Request.URL.Host)
 	})
 
 	return c
 }
 
+func (s *Scraper) baseDelay() time.Duration {
+	// Local files and archives need no politeness delay
+	if s.source != nil || s.replay != nil {
+		return 0
+	}
+	return time.Duration(s.config.Processing.Delay * float64(time.Second))
+}
+
+// applyCrawlDelay registers the rate limit for a host once its robots.txt is
+// loaded, using the larger of the configured delay and the Crawl-delay.
+func (s *Scraper) applyCrawlDelay(host string, data *robotstxt.RobotsData) {
+	// robots.txt may also be loaded only for sitemap discovery.
+	if !s.config.Security.RespectRobots {
+		return
+	}
+
+	delay := s.baseDelay()
+	if group := data.FindGroup(s.getUserAgent()); group.CrawlDelay > delay {
+		s.logger.WithFields(logrus.Fields{
+			"host":        host,
+			"crawl_delay": group.CrawlDelay.String(),
+		}).Info("Honoring robots.txt Crawl-delay")
+		delay = group.CrawlDelay
+	}
+
+	s.limitHost(host, delay)
+}
+
+// isAllowedByRobots reports whether robots.txt permits fetching urlStr.
+// Disallowed URLs are logged and recorded for the end-of-run summary.
+func (s *Scraper) isAllowedByRobots(urlStr string) bool {
+	if !s.config.Security.RespectRobots {
+		return true
+	}
+
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return false
+	}
+
+	data, err := s.robots.get(u)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"host":  u.Host,
+			"error": err.Error(),
+		}).Warn("Failed to load robots.txt, assuming all paths are allowed")
+	}
+
+	if data.TestAgent(robotsPath(u), s.getUserAgent()) {
+		return true
+	}
+
+	if _, seen := s.robotsSkipped.LoadOrStore(urlStr, true); !seen {
+		s.logger.WithFields(logrus.Fields{
+			"url":    urlStr,
+			"reason": "robots_disallowed",
+		}).Info("Skipping page")
+	}
+	return false
+}
+
+// robotsSkippedURLs returns every URL skipped because of robots.txt, sorted.
+func (s *Scraper) robotsSkippedURLs() []string {
+	var urls []string
+	s.robotsSkipped.Range(func(key, _ interface{}) bool {
+		urls = append(urls, key.(string))
+		return true
+	})
+	sort.Strings(urls)
+	return urls
+}
+
+func (s *Scraper) logRobotsSummary() {
+	if !s.config.Security.RespectRobots {
+		return
+	}
+
+	skipped := s.robotsSkippedURLs()
+	s.logger.WithFields(logrus.Fields{
+		"skipped_urls": len(skipped),
+	}).Info("🤖 robots.txt summary")
+
+	for _, u := range skipped {
+		s.logger.WithFields(logrus.Fields{
+			"url":    u,
+			"reason": "robots_disallowed",
+		}).Info("Not fetched")
+	}
+}
+
 func (s *Scraper) getUserAgent() string {
 	for _, engine := range s.config.Engines {
 		if engine.Type == "colly" && engine.UserAgent != "" {
@@ -136,20 +409,39 @@ func (s *Scraper) compilePatterns() error {
 		s.ignorePatterns = append(s.ignorePatterns, re)
 	}
 
+	for _, category := range s.config.SkipCategories.Active() {
+		re, err := regexp.Compile(config.SkipCategoryPattern(category.Patterns))
+		if err != nil {
+			return fmt.Errorf("invalid skip category '%s': %w", category.Name, err)
+		}
+		s.skipRules = append(s.skipRules, skipRule{category: category.Name, re: re})
+	}
+
 	return nil
 }
 
 func (s *Scraper) shouldFollow(urlStr string) bool {
-	// Always skip privacy policy, terms, and legal pages
-	if s.isPrivacyOrLegalURL(urlStr) {
-		s.logger.Debugf("Skipping privacy/legal URL: %s", urlStr)
-		return false
+	follow, _, _ := s.followDecision(urlStr)
+	return follow
+}
+
+// followDecision reports whether urlStr passes the URL filters and the
+// rule that accepted it or, if it does not, which outcome and rule
+// rejected it.
+func (s *Scraper) followDecision(urlStr string) (bool, report.Outcome, string) {
+	if s.source != nil && !s.source.contains(urlStr) {
+		return false, report.OutcomeDisallowedDomain, "outside filesystem source"
+	}
+
+	if category, segment, skip := s.skipCategory(urlStr); skip {
+		s.logSkipCategory(urlStr, category, segment)
+		return false, report.OutcomeSkipCategory, category + ": " + segment
 	}
 
 	if len(s.ignorePatterns) > 0 {
 		for _, re := range s.ignorePatterns {
 			if re.MatchString(urlStr) {
-				return false
+				return false, report.OutcomeIgnored, re.String()
 			}
 		}
 	}
@@ -157,13 +449,13 @@ func (s *Scraper) shouldFollow(urlStr string) bool {
 	if len(s.followPatterns) > 0 {
 		for _, re := range s.followPatterns {
 			if re.MatchString(urlStr) {
-				return true
+				return true, "", "follow pattern: " + re.String()
 			}
 		}
-		return false
+		return false, report.OutcomeNotFollowed, "no follow pattern matched"
 	}
 
-	return true
+	return true, "", "no follow patterns"
 }
 
 func (s *Scraper) isAllowedDomain(urlStr string) bool {
@@ -186,27 +478,42 @@ func (s *Scraper) isAllowedDomain(urlStr string) bool {
 	return false
 }
 
-func (s *Scraper) isPrivacyOrLegalURL(urlStr string) bool {
-	// Common privacy, legal, and non-documentation URLs to skip
-	skipPatterns := []string{
-		"privacy", "terms", "legal", "cookies", "gdpr",
-		"about", "contact", "support", "careers", "jobs",
-		"blog(?!/)", "news", "press", "media",
-		"pricing", "enterprise", "commercial", "sales",
-		"login", "signup", "register", "account", "profile",
-		"404", "error", "maintenance", "status",
-	}
-	
-	for _, pattern := range skipPatterns {
-		if matched, _ := regexp.MatchString("(?i)/("+pattern+")($|[/?#])", urlStr); matched {
-			return true
+// skipCategory returns the first active skip category matching urlStr and
+// the path segment that matched it.
+func (s *Scraper) skipCategory(urlStr string) (string, string, bool) {
+	for _, rule := range s.skipRules {
+		if match := rule.re.FindStringSubmatch(urlStr); match != nil {
+			// A pattern anchored at the end of the URL also matches the
+			// query and fragment after its segment
+			segment := match[1]
+			if i := strings.IndexAny(segment, "?#"); i >= 0 {
+				segment = segment[:i]
+			}
+			return rule.category, segment, true
 		}
 	}
-	return false
+	return "", "", false
+}
+
+// logSkipCategory logs the rule excluding urlStr, once per URL.
+func (s *Scraper) logSkipCategory(urlStr, category, segment string) {
+	if _, seen := s.skipLogged.LoadOrStore(urlStr, true); seen {
+		return
+	}
+	s.logger.WithFields(logrus.Fields{
+		"url":      urlStr,
+		"reason":   "skip_category",
+		"category": category,
+		"segment":  segment,
+	}).Info("Skipping page")
 }
 
 func (s *Scraper) handleHTML(e *colly.HTMLElement) {
-	currentURL := e.Request.URL.String()
+	if s.isOversized(e.Request.URL.String()) {
+		return
+	}
+
+	currentURL := s.canonicalURL(e.Request.URL.String())
 	depth := e.Request.Depth
 	
 	s.logger.WithFields(logrus.Fields{
@@ -219,6 +526,7 @@ func (s *Scraper) handleHTML(e *colly.HTMLElement) {
 			"url":    currentURL,
 			"reason": "disallowed_domain",
 		}).Warn("Skipping page")
+		s.trackOutcome(currentURL, report.OutcomeDisallowedDomain, "")
 		return
 	}
 
@@ -235,6 +543,43 @@ func (s *Scraper) handleHTML(e *colly.HTMLElement) {
 		return
 	}
 
+	// A page declaring another canonical URL is stored under that URL
+	requestURL := currentURL
+	if canonical := s.relCanonical(e); canonical != "" && canonical != currentURL {
+		s.recordAlias(canonical, currentURL)
+		if _, visited := s.visitedURLs.LoadOrStore(canonical, true); visited {
+			s.logger.WithFields(logrus.Fields{
+				"url":       currentURL,
+				"canonical": canonical,
+				"reason":    "canonical_already_visited",
+			}).Debug("Skipping page")
+			s.trackOutcome(currentURL, report.OutcomeCanonicalAlias, canonical)
+			return
+		}
+		s.trackOutcome(currentURL, report.OutcomeCanonicalAlias, canonical)
+		s.trackQueued(canonical, currentURL, depth, "rel=canonical")
+		currentURL = canonical
+	}
+
+	// Captured only once the page is known to be new, so that a start
+	// page reached again through an alias adds its navigation once
+	queued := e.Request.Ctx.Get(ctxQueuedURL)
+	if navigation := s.captureNavigation(e, requestURL); len(navigation) > 0 {
+		s.results.add(&pageResult{queued: queued, url: currentURL, navigation: navigation})
+	}
+
+	if s.dryRun {
+		s.results.add(&pageResult{
+			queued:  queued,
+			url:     currentURL,
+			depth:   depth,
+			outcome: report.OutcomeDiscovered,
+			pageURL: e.Request.URL.String(),
+			links:   s.extractLinks(e),
+		})
+		return
+	}
+
 	title := s.extractTitle(e)
 	s.logger.WithFields(logrus.Fields{
 		"url":   currentURL,
@@ -248,6 +593,7 @@ func (s *Scraper) handleHTML(e *colly.HTMLElement) {
 			"url":    currentURL,
 			"reason": "no_content_found",
 		}).Warn("Skipping page")
+		s.trackOutcome(currentURL, report.OutcomeNoContent, "")
 		return
 	}
 	
@@ -260,23 +606,66 @@ func (s *Scraper) handleHTML(e *colly.HTMLElement) {
 
 	pageContent := &types.PageContent{
 		URL:       currentURL,
+		BaseURL:   pageBaseURL(e),
 		Title:     title,
 		Content:   content,
 		Depth:     depth,
 		Timestamp: time.Now(),
 	}
+	result := &pageResult{
+		queued:  queued,
+		url:     currentURL,
+		title:   title,
+		depth:   depth,
+		outcome: report.OutcomeConverted,
+		pageURL: e.Request.URL.String(),
+		links:   s.extractLinks(e),
+	}
+	etag := e.Response.Headers.Get("ETag")
+	lastModified := e.Response.Headers.Get("Last-Modified")
+
+	s.workers.submit(func() {
+		convertStart := time.Now()
+		markdown, err := s.converter.ConvertToMarkdown(pageContent)
+		if err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"url":   currentURL,
+				"error": err.Error(),
+			}).Error("Failed to convert to markdown")
+			s.track(currentURL, func(e *report.Entry) {
+				e.Outcome = report.OutcomeConversionError
+				e.Error = err.Error()
+			})
+			return
+		}
+		result.markdown = markdown
+		result.convertTime = time.Since(convertStart)
+
+		if s.cache != nil {
+			status := s.cache.Store(&cache.Entry{
+				URL:          currentURL,
+				ETag:         etag,
+				LastModified: lastModified,
+				Title:        title,
+				Markdown:     markdown,
+				Links:        result.links,
+			})
+			s.logger.WithFields(logrus.Fields{
+				"url":    currentURL,
+				"status": string(status),
+			}).Debug("Updated page cache")
+		}
 
-	markdown, err := s.converter.ConvertToMarkdown(pageContent)
-	if err != nil {
-		s.logger.WithFields(logrus.Fields{
-			"url":   currentURL,
-			"error": err.Error(),
-		}).Error("Failed to convert to markdown")
-		return
-	}
+		s.results.add(result)
+	})
+}
 
-	s.aggregator.AddPage(currentURL, title, markdown, depth)
+func (s *Scraper) wasVisited(url string) bool {
+	_, visited := s.visitedURLs.Load(url)
+	return visited
+}
 
+func (s *Scraper) reportProgress() {
 	// Progress reporting for comprehensive scrapes using atomic counter
 	currentCount := atomic.AddInt64(&s.pageCount, 1)
 	if currentCount%10 == 0 && currentCount > 0 {
@@ -285,75 +674,25 @@ func (s *Scraper) handleHTML(e *colly.HTMLElement) {
 			"milestone":       "progress_report",
 		}).Info("📄 Processing milestone reached")
 	}
+}
 
+// followLinksFrom queues the links found on the page at pageURL for the
+// next depth level, unless link following is disabled or the page is
+// already at max depth.
+func (s *Scraper) followLinksFrom(pageURL string, depth int, links []string) []string {
 	// Only follow links if we haven't reached max depth
-	if depth < s.config.Processing.MaxDepth {
-		s.findAndFollowLinks(e)
+	if s.config.Sitemaps.Only {
+		s.logger.Debugf("Not following links from %s (sitemap-only crawl)", pageURL)
+	} else if depth < s.config.Processing.MaxDepth {
+		return s.followLinks(pageURL, depth, links)
 	} else {
-		s.logger.Debugf("Not following links from %s (depth %d >= max %d)", currentURL, depth, s.config.Processing.MaxDepth)
-	}
-}
-
-func (s *Scraper) extractTitle(e *colly.HTMLElement) string {
-	if s.config.Selectors.Title != "" {
-		title := e.ChildText(s.config.Selectors.Title)
-		if title != "" {
-			return s.cleanTitle(strings.TrimSpace(title))
+		s.logger.Debugf("Not following links from %s (depth %d >= max %d)", pageURL, depth, s.config.Processing.MaxDepth)
+		reason := fmt.Sprintf("max_depth %d", s.config.Processing.MaxDepth)
+		for _, link := range links {
+			s.trackSkip(link, pageURL, depth+1, report.OutcomeMaxDepth, reason)
 		}
 	}
-	
-	title := e.ChildText("title")
-	if title != "" {
-		return s.cleanTitle(strings.TrimSpace(title))
-	}
-	
-	return "Untitled"
-}
-
-func (s *Scraper) cleanTitle(title string) string {
-	// Conservative title cleaning - only remove obvious artifacts
-	cleaned := title
-	
-	// First remove feature status indicators and other long descriptive text
-	statusPatterns := []string{
-		`\s*This feature is available in the latest.*?React\s*`,
-		`\s*This feature is available in the latest Canary\s*`,
-		`\s*This feature is available in the latest Experimental version of React\s*`,
-	}
-	
-	for _, pattern := range statusPatterns {
-		re := regexp.MustCompile(`(?i)` + pattern)
-		cleaned = re.ReplaceAllString(cleaned, "")
-	}
-	
-	// Then remove site branding patterns (end of title)
-	brandingPatterns := []string{
-		`\s*–\s*React\s*$`,
-		`\s*-\s*React\s*$`,
-		`\s*\|\s*React\s*$`,
-		`\s*–\s*Stripe\s*$`,
-		`\s*-\s*Stripe\s*$`,
-		`\s*\|\s*Stripe\s*$`,
-		`\s*\|\s*.*Documentation\s*$`,
-		`\s*\|\s*.*Docs\s*$`,
-	}
-	
-	for _, pattern := range brandingPatterns {
-		re := regexp.MustCompile(`(?i)` + pattern)
-		cleaned = re.ReplaceAllString(cleaned, "")
-	}
-	
-	// Only remove consecutive identical words (conservative deduplication)
-	words := strings.Fields(cleaned)
-	var deduped []string
-	for i, word := range words {
-		if i == 0 || !strings.EqualFold(word, words[i-1]) {
-			deduped = append(deduped, word)
-		}
-	}
-	cleaned = strings.Join(deduped, " ")
-	
-	return strings.TrimSpace(cleaned)
+	return nil
 }
 
 func (s *Scraper) extractContent(e *colly.HTMLElement) string {
@@ -387,32 +726,76 @@ func (s *Scraper) extractContent(e *colly.HTMLElement) string {
 	return result
 }
 
-func (s *Scraper) findAndFollowLinks(e *colly.HTMLElement) {
+// pageBaseURL returns the URL relative links of a page resolve against:
+// the URL it was fetched from, or the one its <base href> names.
+func pageBaseURL(e *colly.HTMLElement) string {
+	fetched := e.Request.URL
+	if href, ok := e.DOM.Find("base[href]").First().Attr("href"); ok {
+		if ref, err := url.Parse(strings.TrimSpace(href)); err == nil {
+			return fetched.ResolveReference(ref).String()
+		}
+	}
+	return fetched.String()
+}
+
+// extractLinks returns the absolute URLs linked from the page that pass the
+// follow patterns and domain rules, in document order and without duplicates.
+func (s *Scraper) extractLinks(e *colly.HTMLElement) []string {
+	var links []string
+	seen := make(map[string]bool)
+	parent := e.Request.URL.String()
+	depth := e.Request.Depth + 1
+
 	e.ForEach("a[href]", func(i int, el *colly.HTMLElement) {
 		link := el.Attr("href")
 		if link == "" {
 			return
 		}
 
-		absoluteURL := e.Request.AbsoluteURL(link)
+		absoluteURL := s.canonicalURL(e.Request.AbsoluteURL(link))
+		if absoluteURL == "" || seen[absoluteURL] {
+			return
+		}
 		
-		if !s.shouldFollow(absoluteURL) {
+		if follow, outcome, reason := s.followDecision(absoluteURL); !follow {
+			s.trackSkip(absoluteURL, parent, depth, outcome, reason)
 			return
 		}
 
 		if !s.isAllowedDomain(absoluteURL) {
+			s.trackSkip(absoluteURL, parent, depth, report.OutcomeDisallowedDomain, "")
 			return
 		}
 
-		if _, visited := s.visitedURLs.Load(absoluteURL); visited {
-			return
+		seen[absoluteURL] = true
+		links = append(links, absoluteURL)
+	})
+
+	return links
+}
+
+// followLinks returns the links that still need a visit. They are added
+// to the frontier right away, so that a checkpoint taken mid-crawl still
+// knows about the ones not reached yet.
+func (s *Scraper) followLinks(pageURL string, depth int, links []string) []string {
+	var queued []string
+	for _, link := range links {
+		if _, visited := s.visitedURLs.Load(link); visited {
+			continue
 		}
 
-		s.logger.Debugf("Following link: %s", absoluteURL)
-		if err := e.Request.Visit(absoluteURL); err != nil {
-			s.logger.WithError(err).Warnf("Failed to visit link: %s", absoluteURL)
+		if !s.isAllowedByRobots(link) {
+			s.trackSkip(link, pageURL, depth+1, report.OutcomeRobots, "")
+			continue
 		}
-	})
+
+		s.logger.Debugf("Following link: %s", link)
+		queued = append(queued, link)
+		_, _, rule := s.followDecision(link)
+		s.trackQueued(link, pageURL, depth+1, rule)
+		s.frontier.add(link, depth+1)
+	}
+	return queued
 }
 
 // Run executes the scraper with default context behavior.
@@ -433,119 +816,165 @@ func (s *Scraper) RunWithContext(ctx context.Context) error {
 		"scraping_timeout": s.config.Security.ScrapingTimeout.String(),
 	}).Info("Starting scraper")
 
-	// Create context with scraping timeout
+	var pending []pendingURL
+	if s.config.State.Resume {
+		var err error
+		if pending, err = s.restoreCheckpoint(); err != nil {
+			return fmt.Errorf("failed to resume crawl: %w", err)
+		}
+	}
+	if s.config.State.Dir != "" && !s.dryRun {
+		stopCheckpointing := s.startCheckpointing()
+		defer stopCheckpointing()
+	}
+	if s.cache != nil && !s.dryRun {
+		defer s.saveCache()
+	}
+	if s.config.ReportFile != "" {
+		defer s.writeReport()
+	}
+	if s.archive != nil {
+		defer s.closeArchive()
+	}
+
+	for _, startURL := range s.config.StartURLs {
+		s.startURLs.Store(s.canonicalizer.canonicalize(startURL), true)
+	}
+
+	// Create context with scraping timeout; when it ends, requests in
+	// flight are aborted and the crawl winds down
 	timeoutCtx, cancel := context.WithTimeout(ctx, s.config.Security.ScrapingTimeout)
 	defer cancel()
+	stopCancel := context.AfterFunc(timeoutCtx, s.cancelRun)
+	defer stopCancel()
+
+	seeds := pending
+	var started []string
+	var allErrors []error
+	for _, startURL := range s.config.StartURLs {
+		startURL = s.canonicalURL(startURL)
+		s.logger.WithFields(logrus.Fields{
+			"start_url": startURL,
+		}).Info("Processing start URL")
 
-	done := make(chan error, 1)
-	
-	go func() {
-		defer close(done)
-		
-		var allErrors []error
-		for _, startURL := range s.config.StartURLs {
-			select {
-			case <-timeoutCtx.Done():
-				done <- timeoutCtx.Err()
-				return
-			default:
-				s.logger.WithFields(logrus.Fields{
-					"start_url": startURL,
-				}).Info("Processing start URL")
-				
-				if err := s.visitWithRetry(startURL, DefaultMaxRetries); err != nil {
-					s.logger.WithFields(logrus.Fields{
-						"start_url": startURL,
-						"error":     err.Error(),
-					}).Error("Failed to visit start URL after retries")
-					allErrors = append(allErrors, fmt.Errorf("failed to visit %s: %w", startURL, err))
-				}
-			}
+		if _, visited := s.visitedURLs.Load(startURL); visited {
+			s.logger.WithField("start_url", startURL).Debug("Start URL already visited in a previous run")
+			continue
 		}
 
-		// If all start URLs failed, return error
-		if len(allErrors) == len(s.config.StartURLs) {
-			done <- fmt.Errorf("all start URLs failed: %v", allErrors)
-			return
+		if !s.isAllowedByRobots(startURL) {
+			s.trackSkip(startURL, "", 1, report.OutcomeRobots, "")
+			allErrors = append(allErrors, fmt.Errorf("start URL %s is disallowed by robots.txt", startURL))
+			continue
 		}
 
-		s.collector.Wait()
+		s.trackQueued(startURL, "", 1, "start URL")
+		seeds = append(seeds, pendingURL{URL: startURL, Depth: 1})
+		started = append(started, startURL)
+	}
 
-		finalPageCount := s.aggregator.GetPageCount()
-		s.logger.WithFields(logrus.Fields{
-			"total_pages": finalPageCount,
-		}).Info("🎉 Scraping completed")
-		
-		s.logger.Info("📝 Generating comprehensive markdown output...")
-		
-		if err := s.aggregator.GenerateOutput(); err != nil {
-			done <- fmt.Errorf("failed to generate output: %w", err)
-			return
-		}
+	s.crawl(timeoutCtx, seeds)
+	if timeoutCtx.Err() != nil {
+		return s.finishInterrupted(timeoutCtx)
+	}
 
-		s.logger.WithFields(logrus.Fields{
-			"total_pages":   finalPageCount,
-			"output_file":   s.config.OutputFile,
-			"partial_fails": len(allErrors),
-		}).Info("✅ Documentation scraping completed successfully")
-		
-		// Log any partial failures but don't fail overall if we got some content
-		if len(allErrors) > 0 && finalPageCount > 0 {
-			s.logger.WithFields(logrus.Fields{
-				"failed_urls":   len(allErrors),
-				"success_pages": finalPageCount,
-			}).Warn("⚠️  Some start URLs failed, but scraping succeeded")
-		}
-		
-		done <- nil
-	}()
-
-	select {
-	case err := <-done:
-		return err
-	case <-timeoutCtx.Done():
-		if timeoutCtx.Err() == context.DeadlineExceeded {
+	for _, startURL := range started {
+		if err := s.visitError(startURL); err != nil {
 			s.logger.WithFields(logrus.Fields{
-				"timeout": s.config.Security.ScrapingTimeout.String(),
-				"reason":  "scraping_timeout_exceeded",
-			}).Warn("Scraping timed out - consider increasing scraping_timeout in config")
-		} else {
-			s.logger.WithFields(logrus.Fields{
-				"reason": timeoutCtx.Err().Error(),
-			}).Warn("Scraping cancelled")
+				"start_url": startURL,
+				"error":     err.Error(),
+			}).Error("Failed to visit start URL after retries")
+			allErrors = append(allErrors, fmt.Errorf("failed to visit %s: %w", startURL, err))
 		}
-		return timeoutCtx.Err()
 	}
+
+	sitemapPages := 0
+	if s.config.Sitemaps.Enabled() && timeoutCtx.Err() == nil {
+		sitemapPages = s.visitSitemapURLs(timeoutCtx)
+	}
+	if s.source != nil && timeoutCtx.Err() == nil {
+		sitemapPages += s.visitSourceFiles(timeoutCtx)
+	}
+	if timeoutCtx.Err() != nil {
+		return s.finishInterrupted(timeoutCtx)
+	}
+
+	// If all start URLs failed and sitemaps or local files gave us nothing, return error
+	if len(allErrors) == len(s.config.StartURLs) && sitemapPages == 0 {
+		s.logRobotsSummary()
+		s.logLimitsSummary()
+		s.logThrottleSummary()
+		s.logFailureSummary()
+		s.logImageSummary()
+		return fmt.Errorf("all start URLs failed: %v", allErrors)
+	}
+
+	s.logRobotsSummary()
+	s.logLimitsSummary()
+	s.logThrottleSummary()
+	s.logFailureSummary()
+	s.logImageSummary()
+
+	if s.dryRun {
+		s.completed.Store(true)
+		return nil
+	}
+
+	finalPageCount := s.aggregator.GetPageCount()
+	s.logger.WithFields(logrus.Fields{
+		"total_pages": finalPageCount,
+	}).Info("🎉 Scraping completed")
+	
+	s.logger.Info("📝 Generating comprehensive markdown output...")
+	
+	if err := s.aggregator.GenerateOutput(); err != nil {
+		return fmt.Errorf("failed to generate output: %w", err)
+	}
+	s.logLinkSummary()
+
+	s.completed.Store(true)
+
+	s.logger.WithFields(logrus.Fields{
+		"total_pages":   finalPageCount,
+		"output_file":   s.config.OutputFile,
+		"partial_fails": len(allErrors),
+		"failed_urls":   len(s.failedURLs()),
+	}).Info("✅ Documentation scraping completed successfully")
+	
+	// Log any partial failures but don't fail overall if we got some content
+	if len(allErrors) > 0 && finalPageCount > 0 {
+		s.logger.WithFields(logrus.Fields{
+			"failed_urls":   len(allErrors),
+			"success_pages": finalPageCount,
+		}).Warn("⚠️  Some start URLs failed, but scraping succeeded")
+	}
+	
+	return nil
 }
 
-func (s *Scraper) visitWithRetry(url string, maxRetries int) error {
-	var lastErr error
-	for i := 0; i < maxRetries; i++ {
-		if err := s.collector.Visit(url); err != nil {
-			lastErr = err
-			if i < maxRetries-1 { // Don't sleep on last attempt
-				backoff := time.Duration(math.Pow(2, float64(i))) * DefaultBackoffBase
-				if backoff > MaxBackoffDelay {
-					backoff = MaxBackoffDelay
-				}
-				s.logger.WithFields(logrus.Fields{
-					"url":         url,
-					"retry":       i + 1,
-					"max_retries": maxRetries,
-					"backoff":     backoff.String(),
-					"error":       err.Error(),
-				}).Debug("Retrying after error")
-				time.Sleep(backoff)
-			}
+// visitSitemapURLs crawls every page listed in the sitemaps that has not
+// been visited yet and returns how many were queued.
+func (s *Scraper) visitSitemapURLs(ctx context.Context) int {
+	urls := s.sitemapURLs(ctx)
+	s.logger.WithFields(logrus.Fields{
+		"urls": len(urls),
+	}).Info("🗺️  Queueing sitemap URLs")
+
+	var seeds []pendingURL
+	for _, pageURL := range urls {
+		if ctx.Err() != nil {
+			break
+		}
+		if _, visited := s.visitedURLs.Load(pageURL); visited {
 			continue
 		}
-		if i > 0 {
-			s.logger.WithFields(logrus.Fields{
-				"url":     url,
-				"retries": i,
-			}).Info("Successfully recovered after retries")
+		if !s.isAllowedByRobots(pageURL) {
+			s.trackSkip(pageURL, "", 1, report.OutcomeRobots, "")
+			continue
 		}
-		return nil
+		seeds = append(seeds, pendingURL{URL: pageURL, Depth: 1})
 	}
-	return fmt.Errorf("failed after %d retries: %w", maxRetries, lastErr)
-}
\ No newline at end of file
+
+	s.crawl(ctx, seeds)
+	return len(seeds)
+}
@@ -1,15 +1,42 @@
 package scraper
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"io"
+	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"testing"
 	"time"
 
+	"github.com/gocolly/colly/v2"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/vladkampov/markdocify/internal/cache"
 	"github.com/vladkampov/markdocify/internal/config"
+	"github.com/vladkampov/markdocify/internal/report"
 )
 
 func TestIsAllowedDomain(t *testing.T) {
@@ -66,32 +93,55 @@ func TestIsAllowedDomain(t *testing.T) {
 	}
 }
 
-func TestIsPrivacyOrLegalURL(t *testing.T) {
+func TestSkipCategory(t *testing.T) {
 	tests := []struct {
-		name     string
-		url      string
-		expected bool
+		name       string
+		url        string
+		categories config.SkipCategoriesConfig
+		expected   bool
+		category   string
+		segment    string
 	}{
 		{
 			name:     "privacy policy URL",
 			url:      "https://example.com/privacy",
 			expected: true,
+			category: "legal",
+			segment:  "privacy",
 		},
 		{
 			name:     "terms URL",
 			url:      "https://example.com/terms",
 			expected: true,
+			category: "legal",
+			segment:  "terms",
 		},
 		{
 			name:     "about page",
 			url:      "https://example.com/about",
 			expected: true,
+			category: "company",
+			segment:  "about",
 		},
 		{
 			name:     "documentation URL",
 			url:      "https://example.com/docs/api",
 			expected: false,
 		},
+		{
+			name:     "blog index",
+			url:      "https://example.com/Blog",
+			expected: true,
+			category: "news",
+			segment:  "Blog",
+		},
+		{
+			name:     "blog index with query",
+			url:      "https://example.com/blog?page=2",
+			expected: true,
+			category: "news",
+			segment:  "blog",
+		},
 		{
 			name:     "blog post URL should not be skipped",
 			url:      "https://example.com/blog/post",
@@ -101,14 +151,54 @@ func TestIsPrivacyOrLegalURL(t *testing.T) {
 			name:     "careers page",
 			url:      "https://example.com/careers",
 			expected: true,
+			category: "company",
+			segment:  "careers",
+		},
+		{
+			name:     "status docs kept by default",
+			url:      "https://example.com/api/status",
+			expected: false,
+		},
+		{
+			name:     "error docs kept by default",
+			url:      "https://example.com/docs/error",
+			expected: false,
+		},
+		{
+			name:       "opt-in category",
+			url:        "https://example.com/api/status",
+			categories: config.SkipCategoriesConfig{Enabled: []string{"site_status"}},
+			expected:   true,
+			category:   "site_status",
+			segment:    "status",
+		},
+		{
+			name:       "disabled default category",
+			url:        "https://example.com/about",
+			categories: config.SkipCategoriesConfig{Disabled: []string{"company"}},
+			expected:   false,
+		},
+		{
+			name: "custom category",
+			url:  "https://example.com/docs/changelog?page=2",
+			categories: config.SkipCategoriesConfig{
+				Custom: map[string][]string{"changes": {"changelog", "release-notes"}},
+			},
+			expected: true,
+			category: "changes",
+			segment:  "changelog",
 		},
 	}
 
-	scraper := &Scraper{}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := scraper.isPrivacyOrLegalURL(tt.url)
-			assert.Equal(t, tt.expected, result)
+			scraper := &Scraper{config: &config.Config{SkipCategories: tt.categories}}
+			require.NoError(t, scraper.compilePatterns())
+
+			category, segment, skip := scraper.skipCategory(tt.url)
+			assert.Equal(t, tt.expected, skip)
+			assert.Equal(t, tt.category, category)
+			assert.Equal(t, tt.segment, segment)
 		})
 	}
 }
@@ -146,15 +236,96 @@ func TestCleanTitle(t *testing.T) {
 		},
 	}
 
-	scraper := &Scraper{}
+	cleaner, err := newTitleCleaner(config.TitleRulesConfig{
+		Presets: []string{"react", "stripe", "docs"},
+	})
+	require.NoError(t, err)
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := scraper.cleanTitle(tt.input)
+			result := cleaner.clean(tt.input)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
 }
 
+func TestTitleRules(t *testing.T) {
+	cleaner, err := newTitleCleaner(config.TitleRulesConfig{
+		StripPrefixes: []string{`Docs:`},
+		StripSuffixes: []string{`[·—|]\s*(Vue\.js|Next\.js)`},
+		Replacements: []config.TitleReplacement{
+			{Pattern: `\s*\(beta\)`, Replacement: ""},
+			{Pattern: `&`, Replacement: "and"},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Reactivity Fundamentals", cleaner.clean("Reactivity Fundamentals · Vue.js"))
+	assert.Equal(t, "Routing", cleaner.clean("Routing — Next.js"))
+	assert.Equal(t, "Install", cleaner.clean("docs: Install"))
+	assert.Equal(t, "Cache Components and ISR", cleaner.clean("Cache Components (Beta) & ISR | Next.js"))
+	// The default docs preset still applies
+	assert.Equal(t, "Guides", cleaner.clean("Guides | Acme Documentation"))
+}
+
+func TestExtractTitleFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var head, body string
+		switch r.URL.Path {
+		case "/docs/selector":
+			head, body = `<title>Head Title</title>`, `<h1>Heading – React</h1>`
+		case "/docs/title":
+			head = `<title>Head Title | Acme Docs</title><meta property="og:title" content="OG Title">`
+		case "/docs/og":
+			head = `<meta property="og:title" content="OG Title">`
+		}
+		fmt.Fprintf(w, `<html><head>%s</head><body>%s<main><p>Content</p></main></body></html>`, head, body)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		path     string
+		rules    config.TitleRulesConfig
+		expected string
+	}{
+		{name: "selector", path: "/docs/selector", rules: config.TitleRulesConfig{Presets: []string{"react"}}, expected: "Heading"},
+		{name: "title element", path: "/docs/title", expected: "Head Title"},
+		{name: "og:title", path: "/docs/og", expected: "OG Title"},
+		{name: "URL slug", path: "/docs/getting-started_guide.html", expected: "Getting Started Guide"},
+		{
+			name:     "custom order",
+			path:     "/docs/title",
+			rules:    config.TitleRulesConfig{Fallback: []string{config.TitleSourceOGTitle, config.TitleSourceTitle}},
+			expected: "OG Title",
+		},
+		{
+			name:     "no source left",
+			path:     "/docs/empty",
+			rules:    config.TitleRulesConfig{Fallback: []string{config.TitleSourceSelector}},
+			expected: "Untitled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cleaner, err := newTitleCleaner(tt.rules)
+			require.NoError(t, err)
+			scraper := &Scraper{
+				config: &config.Config{Selectors: config.SelectorConfig{Title: "h1"}},
+				titles: cleaner,
+			}
+
+			var title string
+			c := colly.NewCollector()
+			c.OnHTML("html", func(e *colly.HTMLElement) {
+				title = scraper.extractTitle(e)
+			})
+			require.NoError(t, c.Visit(server.URL+tt.path))
+			assert.Equal(t, tt.expected, title)
+		})
+	}
+}
+
 func TestScraperIntegration(t *testing.T) {
 	// Create a test HTTP server
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -233,7 +404,7 @@ func TestScraperIntegration(t *testing.T) {
 	assert.Greater(t, pageCount, 0, "Should have scraped at least one page")
 }
 
-func TestVisitWithRetry(t *testing.T) {
+func TestStartURLRetry(t *testing.T) {
 	attemptCount := 0
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		attemptCount++
@@ -242,7 +413,7 @@ func TestVisitWithRetry(t *testing.T) {
 			return
 		}
 		w.WriteHeader(http.StatusOK)
-		if _, err := w.Write([]byte("<html><body><h1>Success</h1></body></html>")); err != nil {
+		if _, err := w.Write([]byte("<html><body><main><h1>Success</h1><p>Retried page</p></main></body></html>")); err != nil {
 			t.Errorf("Failed to write response: %v", err)
 		}
 	}))
@@ -251,13 +422,23 @@ func TestVisitWithRetry(t *testing.T) {
 	// Test that retry logic is called the expected number of times
 	// by creating a new scraper for each test to avoid visited URL cache
 	for i := 0; i < 3; i++ {
+		// Use a unique path for each attempt
+		testURL := server.URL + fmt.Sprintf("/test-retry-path-%d", i)
+
 		cfg := &config.Config{
+			OutputFile: t.TempDir() + "/retry.md",
+			StartURLs:  []string{testURL},
 			Processing: config.ProcessingConfig{
+				MaxDepth:    1,
 				Concurrency: 1,
 				Delay:       0.1,
 			},
+			Retry: config.RetryConfig{
+				MaxAttempts: 1, // Single attempt per scraper
+			},
 			Security: config.SecurityConfig{
-				RequestTimeout: 5 * time.Second,
+				RequestTimeout:  5 * time.Second,
+				ScrapingTimeout: 10 * time.Second,
 			},
 			Monitoring: config.MonitoringConfig{
 				LogLevel: "error",
@@ -267,11 +448,8 @@ func TestVisitWithRetry(t *testing.T) {
 		scraper, err := New(cfg)
 		require.NoError(t, err)
 
-		// Use a unique path for each attempt
-		testURL := server.URL + fmt.Sprintf("/test-retry-path-%d", i)
-		
 		// This will trigger server response based on attemptCount
-		err = scraper.visitWithRetry(testURL, 1) // Single attempt per scraper
+		err = scraper.RunWithContext(context.Background())
 		if i < 2 {
 			assert.Error(t, err, "Should fail on attempts 1 and 2")
 		} else {
@@ -483,6 +661,7 @@ func TestExtractTitle(t *testing.T) {
 			// For now, test through integration
 			err = scraper.collector.Visit(server.URL)
 			assert.NoError(t, err)
+			scraper.collector.Wait()
 		})
 	}
 }
@@ -515,11 +694,16 @@ func TestFindAndFollowLinks(t *testing.T) {
 	defer server.Close()
 
 	cfg := &config.Config{
+		OutputFile: t.TempDir() + "/links.md",
+		StartURLs:  []string{server.URL},
 		Processing: config.ProcessingConfig{
 			MaxDepth:    2,
 			Concurrency: 1,
 			Delay:       0.1,
 		},
+		Retry: config.RetryConfig{
+			MaxAttempts: 1,
+		},
 		Security: config.SecurityConfig{
 			RequestTimeout:  5 * time.Second,
 			ScrapingTimeout: 10 * time.Second,
@@ -532,13 +716,2067 @@ func TestFindAndFollowLinks(t *testing.T) {
 	scraper, err := New(cfg)
 	require.NoError(t, err)
 
-	err = scraper.collector.Visit(server.URL)
+	err = scraper.RunWithContext(context.Background())
 	assert.NoError(t, err)
 
-	// Give time for async processing
-	scraper.collector.Wait()
-
 	// Check that multiple pages were processed
 	pageCount := scraper.aggregator.GetPageCount()
 	assert.Greater(t, pageCount, 1, "Should have followed some links")
-}
\ No newline at end of file
+}
+func TestRespectRobots(t *testing.T) {
+	privateHits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			_, _ = w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+		case "/":
+			_, _ = w.Write([]byte(`<html><body><main>
+				<p>Home</p>
+				<a href="/public">Public</a>
+				<a href="/private/secret">Private</a>
+			</main></body></html>`))
+		case "/public":
+			_, _ = w.Write([]byte(`<html><body><main><p>Public page</p></main></body></html>`))
+		case "/private/secret":
+			privateHits++
+			_, _ = w.Write([]byte(`<html><body><main><p>Secret page</p></main></body></html>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		OutputFile: t.TempDir() + "/robots.md",
+		StartURLs:  []string{server.URL + "/"},
+		Processing: config.ProcessingConfig{
+			MaxDepth:    2,
+			Concurrency: 1,
+		},
+		Security: config.SecurityConfig{
+			RespectRobots:   true,
+			RequestTimeout:  5 * time.Second,
+			ScrapingTimeout: 10 * time.Second,
+		},
+		Monitoring: config.MonitoringConfig{
+			LogLevel: "error",
+		},
+	}
+
+	scraper, err := New(cfg)
+	require.NoError(t, err)
+
+	err = scraper.Run()
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, privateHits, "Disallowed URL should never be fetched")
+	assert.Equal(t, 2, scraper.aggregator.GetPageCount())
+	assert.Equal(t, []string{server.URL + "/private/secret"}, scraper.robotsSkippedURLs())
+}
+
+func TestRespectRobots_DisallowedStartURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			_, _ = w.Write([]byte("User-agent: markdocify\nDisallow: /\nCrawl-delay: 5\n"))
+			return
+		}
+		_, _ = w.Write([]byte(`<html><body><main><p>Content</p></main></body></html>`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		StartURLs: []string{server.URL + "/docs"},
+		Engines: []config.EngineConfig{
+			{Type: "colly", UserAgent: "markdocify/1.0"},
+		},
+		Processing: config.ProcessingConfig{
+			MaxDepth:    1,
+			Concurrency: 1,
+		},
+		Security: config.SecurityConfig{
+			RespectRobots:   true,
+			RequestTimeout:  5 * time.Second,
+			ScrapingTimeout: 10 * time.Second,
+		},
+		Monitoring: config.MonitoringConfig{
+			LogLevel: "error",
+		},
+	}
+
+	scraper, err := New(cfg)
+	require.NoError(t, err)
+
+	err = scraper.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "disallowed by robots.txt")
+	assert.Equal(t, []string{server.URL + "/docs"}, scraper.robotsSkippedURLs())
+}
+
+func TestRobotsCache_SlowHostDoesNotBlockOthers(t *testing.T) {
+	release := make(chan struct{})
+	var slowFetches atomic.Int32
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slowFetches.Add(1)
+		<-release
+		_, _ = w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+	}))
+	defer slow.Close()
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("User-agent: *\nAllow: /\n"))
+	}))
+	defer fast.Close()
+
+	cache := newRobotsCache(http.DefaultClient, "markdocify")
+	slowURL, _ := url.Parse(slow.URL + "/docs")
+	fastURL, _ := url.Parse(fast.URL + "/docs")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data, err := cache.get(slowURL)
+			assert.NoError(t, err)
+			assert.False(t, data.TestAgent("/private", "markdocify"))
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := cache.get(fastURL)
+		assert.NoError(t, err)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("robots.txt of one host waited for another host's")
+	}
+
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), slowFetches.Load(), "robots.txt is fetched once per host")
+}
+
+func TestSitemapSeeding(t *testing.T) {
+	var serverURL string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			_, _ = w.Write([]byte("User-agent: *\nAllow: /\nSitemap: " + serverURL + "/sitemap-index.xml\n"))
+		case "/sitemap-index.xml":
+			_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<sitemap><loc>` + serverURL + `/sitemap-docs.xml.gz</loc></sitemap>
+</sitemapindex>`))
+		case "/sitemap-docs.xml.gz":
+			w.Header().Set("Content-Type", "application/x-gzip")
+			_, _ = w.Write(gzipString(t, `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<url><loc>`+serverURL+`/docs/orphan</loc></url>
+	<url><loc>`+serverURL+`/docs/ignored.pdf</loc></url>
+	<url><loc>https://external.com/docs/page</loc></url>
+</urlset>`))
+		case "/docs":
+			_, _ = w.Write([]byte(`<html><body><main><p>Start</p><a href="/docs/linked">Linked</a></main></body></html>`))
+		case "/docs/linked":
+			_, _ = w.Write([]byte(`<html><body><main><p>Linked page</p></main></body></html>`))
+		case "/docs/orphan":
+			_, _ = w.Write([]byte(`<html><body><main><p>Orphan page</p></main></body></html>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	serverURL = server.URL
+
+	cfg := &config.Config{
+		BaseURL:        server.URL,
+		OutputFile:     t.TempDir() + "/sitemap.md",
+		StartURLs:      []string{server.URL + "/docs"},
+		FollowPatterns: []string{"^" + regexp.QuoteMeta(server.URL) + "/docs"},
+		IgnorePatterns: []string{`.*\.pdf$`},
+		Sitemaps: config.SitemapConfig{
+			Discover: true,
+			Only:     true,
+		},
+		Processing: config.ProcessingConfig{
+			MaxDepth:    3,
+			Concurrency: 1,
+		},
+		Security: config.SecurityConfig{
+			RequestTimeout:  5 * time.Second,
+			ScrapingTimeout: 10 * time.Second,
+		},
+		Monitoring: config.MonitoringConfig{
+			LogLevel: "error",
+		},
+	}
+
+	scraper, err := New(cfg)
+	require.NoError(t, err)
+
+	err = scraper.Run()
+	require.NoError(t, err)
+
+	_, orphanVisited := scraper.visitedURLs.Load(server.URL + "/docs/orphan")
+	_, linkedVisited := scraper.visitedURLs.Load(server.URL + "/docs/linked")
+	assert.True(t, orphanVisited, "Sitemap entry should be scraped")
+	assert.False(t, linkedVisited, "Links should not be followed in sitemap-only mode")
+	assert.Equal(t, 2, scraper.aggregator.GetPageCount())
+}
+
+func gzipString(t *testing.T, s string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(s))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestParseSitemap(t *testing.T) {
+	doc, err := parseSitemap(strings.NewReader(`<urlset><url><loc> https://example.com/a </loc></url></urlset>`))
+	require.NoError(t, err)
+	require.Len(t, doc.URLs, 1)
+	assert.Equal(t, "https://example.com/a", strings.TrimSpace(doc.URLs[0].Loc))
+
+	_, err = parseSitemap(strings.NewReader(`<html><body>not a sitemap</body></html>`))
+	assert.Error(t, err)
+}
+
+func TestResumeFromCheckpoint(t *testing.T) {
+	release := make(chan struct{})
+	blockPage2 := true
+	var mu sync.Mutex
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			_, _ = w.Write([]byte(`<html><body><main><h1>Home</h1>
+				<a href="/page1">Page 1</a>
+				<a href="/page2">Page 2</a>
+				<a href="/page3">Page 3</a>
+			</main></body></html>`))
+		case "/page2":
+			mu.Lock()
+			block := blockPage2
+			mu.Unlock()
+			if block {
+				<-release
+			}
+			fallthrough
+		case "/page1", "/page3":
+			_, _ = w.Write([]byte(`<html><body><main><h1>` + r.URL.Path + `</h1><p>Content of ` + r.URL.Path + `</p></main></body></html>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	newConfig := func(output, stateDir string, timeout time.Duration) *config.Config {
+		return &config.Config{
+			Name:       "Resume Test",
+			OutputFile: output,
+			StartURLs:  []string{server.URL + "/"},
+			Processing: config.ProcessingConfig{
+				MaxDepth:    3,
+				Concurrency: 1,
+				GenerateTOC: true,
+			},
+			Security: config.SecurityConfig{
+				RequestTimeout:  10 * time.Second,
+				ScrapingTimeout: timeout,
+			},
+			State: config.StateConfig{
+				Dir: stateDir,
+			},
+			Monitoring: config.MonitoringConfig{
+				LogLevel: "error",
+			},
+		}
+	}
+
+	tmp := t.TempDir()
+	stateDir := tmp + "/state"
+
+	// First run times out while page2 is in flight
+	interrupted, err := New(newConfig(tmp+"/interrupted.md", stateDir, 500*time.Millisecond))
+	require.NoError(t, err)
+	err = interrupted.Run()
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	cp, err := loadCheckpoint(stateDir + "/" + CheckpointFileName)
+	require.NoError(t, err)
+	assert.False(t, cp.Completed)
+	assert.Contains(t, cp.Pending, pendingURL{URL: server.URL + "/page2", Depth: 2})
+
+	mu.Lock()
+	blockPage2 = false
+	mu.Unlock()
+
+	resumedCfg := newConfig(tmp+"/resumed.md", stateDir, 10*time.Second)
+	resumedCfg.State.Resume = true
+	resumed, err := New(resumedCfg)
+	require.NoError(t, err)
+	require.NoError(t, resumed.Run())
+
+	fresh, err := New(newConfig(tmp+"/fresh.md", tmp+"/fresh-state", 10*time.Second))
+	require.NoError(t, err)
+	require.NoError(t, fresh.Run())
+
+	resumedOutput, err := os.ReadFile(tmp + "/resumed.md")
+	require.NoError(t, err)
+	freshOutput, err := os.ReadFile(tmp + "/fresh.md")
+	require.NoError(t, err)
+	assert.Equal(t, string(freshOutput), string(resumedOutput))
+	assert.Equal(t, 4, resumed.aggregator.GetPageCount())
+
+	cp, err = loadCheckpoint(stateDir + "/" + CheckpointFileName)
+	require.NoError(t, err)
+	assert.True(t, cp.Completed)
+	assert.Empty(t, cp.Pending)
+}
+
+func TestResumeRejectsForeignCheckpoint(t *testing.T) {
+	stateDir := t.TempDir()
+	cfg := &config.Config{
+		Name:      "Other Docs",
+		StartURLs: []string{"http://127.0.0.1:1/"},
+		State:     config.StateConfig{Dir: stateDir, Resume: true},
+		Monitoring: config.MonitoringConfig{
+			LogLevel: "error",
+		},
+	}
+	require.NoError(t, os.WriteFile(stateDir+"/"+CheckpointFileName, []byte(`{"name":"Some Docs"}`), 0600))
+
+	scraper, err := New(cfg)
+	require.NoError(t, err)
+
+	err = scraper.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `belongs to "Some Docs"`)
+}
+
+func TestIncrementalScrape(t *testing.T) {
+	var mu sync.Mutex
+	pages := map[string]string{
+		"/":        `<a href="/stable">Stable</a><a href="/edited">Edited</a><a href="/removed">Removed</a>`,
+		"/stable":  `<p>Stable page</p><a href="/child">Child</a>`,
+		"/child":   `<p>Child page</p>`,
+		"/edited":  `<p>Original text</p>`,
+		"/removed": `<p>Removed page</p>`,
+	}
+	notModified := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		body, ok := pages[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		etag := `"` + cache.Hash(body)[:16] + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		_, _ = w.Write([]byte(`<html><body><main><h1>` + r.URL.Path + `</h1>` + body + `</main></body></html>`))
+	}))
+	defer server.Close()
+
+	tmp := t.TempDir()
+	newScraper := func() *Scraper {
+		cfg := &config.Config{
+			Name:       "Incremental Test",
+			OutputFile: tmp + "/out.md",
+			StartURLs:  []string{server.URL + "/"},
+			Processing: config.ProcessingConfig{
+				MaxDepth:    3,
+				Concurrency: 1,
+			},
+			Security: config.SecurityConfig{
+				RequestTimeout:  5 * time.Second,
+				ScrapingTimeout: 10 * time.Second,
+			},
+			Cache: config.CacheConfig{
+				Dir: tmp + "/cache",
+			},
+			Monitoring: config.MonitoringConfig{
+				LogLevel: "error",
+			},
+		}
+		s, err := New(cfg)
+		require.NoError(t, err)
+		return s
+	}
+
+	first := newScraper()
+	require.NoError(t, first.Run())
+	assert.Equal(t, 5, first.cache.Stats().New)
+
+	mu.Lock()
+	pages["/edited"] = `<p>Updated text</p>`
+	pages["/"] = `<a href="/stable">Stable</a><a href="/edited">Edited</a><a href="/added">Added</a>`
+	pages["/added"] = `<p>Added page</p>`
+	delete(pages, "/removed")
+	mu.Unlock()
+
+	second := newScraper()
+	require.NoError(t, second.Run())
+
+	stats := second.cache.Stats()
+	assert.Equal(t, 2, stats.Fresh, "stable and child pages should be revalidated")
+	assert.Equal(t, 2, stats.Changed, "home and edited pages changed")
+	assert.Equal(t, 1, stats.New)
+	assert.Equal(t, 1, stats.Gone)
+	assert.Equal(t, 2, notModified)
+
+	output, err := os.ReadFile(tmp + "/out.md")
+	require.NoError(t, err)
+	assert.Contains(t, string(output), "Stable page", "Cached markdown should be reused on 304")
+	assert.Contains(t, string(output), "Child page", "Links from a 304 page should still be followed")
+	assert.Contains(t, string(output), "Updated text")
+	assert.NotContains(t, string(output), "Removed page")
+}
+
+func TestMaxFileSize(t *testing.T) {
+	big := "<html><body><main><p>" + strings.Repeat("x", 4096) + "</p></main></body></html>"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			_, _ = w.Write([]byte(`<html><body><main>
+				<p>Home</p>
+				<a href="/small">Small</a>
+				<a href="/declared">Declared</a>
+				<a href="/streamed">Streamed</a>
+			</main></body></html>`))
+		case "/small":
+			_, _ = w.Write([]byte(`<html><body><main><p>Small page</p></main></body></html>`))
+		case "/declared":
+			w.Header().Set("Content-Length", fmt.Sprint(len(big)))
+			_, _ = w.Write([]byte(big))
+		case "/streamed":
+			// Flushing first forces a chunked response without Content-Length
+			w.(http.Flusher).Flush()
+			_, _ = w.Write([]byte(big))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		OutputFile: t.TempDir() + "/limits.md",
+		StartURLs:  []string{server.URL + "/"},
+		Processing: config.ProcessingConfig{
+			MaxDepth:    2,
+			Concurrency: 1,
+		},
+		Security: config.SecurityConfig{
+			MaxFileSizeBytes: 1024,
+			RequestTimeout:   5 * time.Second,
+			ScrapingTimeout:  10 * time.Second,
+		},
+		Monitoring: config.MonitoringConfig{
+			LogLevel: "error",
+		},
+	}
+
+	scraper, err := New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, scraper.Run())
+
+	assert.Equal(t, 2, scraper.aggregator.GetPageCount())
+	assert.Equal(t, []string{server.URL + "/declared", server.URL + "/streamed"}, scraper.oversizedURLs())
+}
+
+func TestMaxPagesStopsCrawl(t *testing.T) {
+	var hits int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if r.URL.Path == "/" {
+			_, _ = w.Write([]byte(`<html><body><main>
+				<p>Home</p>
+				<a href="/p1">1</a><a href="/p2">2</a><a href="/p3">3</a><a href="/p4">4</a>
+			</main></body></html>`))
+			return
+		}
+		_, _ = fmt.Fprintf(w, `<html><body><main><p>Page %s</p></main></body></html>`, r.URL.Path)
+	}))
+	defer server.Close()
+
+	outputFile := t.TempDir() + "/budget.md"
+	cfg := &config.Config{
+		OutputFile: outputFile,
+		StartURLs:  []string{server.URL + "/"},
+		Processing: config.ProcessingConfig{
+			MaxDepth:    2,
+			Concurrency: 1,
+			MaxPages:    2,
+		},
+		Output: config.OutputConfig{
+			IncludeMetadata: true,
+		},
+		Security: config.SecurityConfig{
+			RequestTimeout:  5 * time.Second,
+			ScrapingTimeout: 10 * time.Second,
+		},
+		Monitoring: config.MonitoringConfig{
+			LogLevel: "error",
+		},
+	}
+
+	scraper, err := New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, scraper.Run())
+
+	assert.Equal(t, 2, scraper.aggregator.GetPageCount())
+	assert.Equal(t, 3, hits, "Requests after the budget is reached should not be sent")
+
+	data, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "Output budget reached")
+}
+
+func TestCanonicalize(t *testing.T) {
+	c := newCanonicalizer(config.CanonicalConfig{
+		DropQueryParams: []string{"utm_*", "ref"},
+		TrailingSlash:   config.TrailingSlashStrip,
+	})
+
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"https://Example.COM/docs/x", "https://example.com/docs/x"},
+		{"https://example.com/docs/x/", "https://example.com/docs/x"},
+		{"https://example.com/docs/x#intro", "https://example.com/docs/x"},
+		{"https://example.com/docs/x?utm_source=news&utm_medium=mail", "https://example.com/docs/x"},
+		{"https://example.com/docs/x?ref=nav&page=2", "https://example.com/docs/x?page=2"},
+		{"https://example.com/docs/x?b=2&a=1", "https://example.com/docs/x?a=1&b=2"},
+		{"https://example.com/docs/index.html", "https://example.com/docs"},
+		{"https://example.com:443/docs", "https://example.com/docs"},
+		{"https://example.com", "https://example.com/"},
+		{"https://example.com/", "https://example.com/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			assert.Equal(t, tt.expected, c.canonicalize(tt.url))
+		})
+	}
+
+	t.Run("add trailing slash", func(t *testing.T) {
+		c := newCanonicalizer(config.CanonicalConfig{TrailingSlash: config.TrailingSlashAdd})
+		assert.Equal(t, "https://example.com/docs/x/", c.canonicalize("https://example.com/docs/x"))
+		assert.Equal(t, "https://example.com/docs/", c.canonicalize("https://example.com/docs/index.html"))
+		assert.Equal(t, "https://example.com/docs/guide.pdf", c.canonicalize("https://example.com/docs/guide.pdf"))
+	})
+
+	t.Run("keep rules", func(t *testing.T) {
+		c := newCanonicalizer(config.CanonicalConfig{
+			TrailingSlash:  config.TrailingSlashKeep,
+			KeepFragments:  true,
+			KeepIndexFiles: true,
+		})
+		assert.Equal(t, "https://example.com/docs/index.html#top", c.canonicalize("https://example.com/docs/index.html#top"))
+		assert.Equal(t, "https://example.com/docs/", c.canonicalize("https://example.com/docs/"))
+	})
+}
+
+func TestCanonicalURLDeduplication(t *testing.T) {
+	var mu sync.Mutex
+	hits := make(map[string]int)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.RequestURI()]++
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/docs":
+			_, _ = w.Write([]byte(`<html><body><main>
+				<p>Docs home</p>
+				<a href="/docs/x">X</a>
+				<a href="/docs/x/">X with slash</a>
+				<a href="/docs/x#intro">X intro</a>
+				<a href="/docs/x?utm_source=home">X tracked</a>
+				<a href="/docs/mirror">Mirror</a>
+			</main></body></html>`))
+		case "/docs/x":
+			_, _ = w.Write([]byte(`<html><body><main><p>Page X</p></main></body></html>`))
+		case "/docs/mirror":
+			_, _ = w.Write([]byte(`<html><head><link rel="canonical" href="/docs/x"></head>
+				<body><main><p>Page X, served again</p></main></body></html>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	outputFile := t.TempDir() + "/canonical.md"
+	cfg := &config.Config{
+		OutputFile: outputFile,
+		StartURLs:  []string{server.URL + "/docs/"},
+		Canonicalize: config.CanonicalConfig{
+			DropQueryParams: config.DefaultDropQueryParams,
+			TrailingSlash:   config.TrailingSlashStrip,
+		},
+		Processing: config.ProcessingConfig{
+			MaxDepth:    2,
+			Concurrency: 1,
+		},
+		Output: config.OutputConfig{
+			IncludeMetadata: true,
+		},
+		Security: config.SecurityConfig{
+			RequestTimeout:  5 * time.Second,
+			ScrapingTimeout: 10 * time.Second,
+		},
+		Monitoring: config.MonitoringConfig{
+			LogLevel: "error",
+		},
+	}
+
+	scraper, err := New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, scraper.Run())
+
+	// colly drops fragments from links itself, so "#intro" never shows up as an alias
+	assert.Equal(t, 1, hits["/docs/x"], "Every variant of /docs/x should be fetched once")
+	assert.Equal(t, 2, scraper.aggregator.GetPageCount())
+	assert.Equal(t, []string{
+		server.URL + "/docs/mirror",
+		server.URL + "/docs/x/",
+		server.URL + "/docs/x?utm_source=home",
+	}, scraper.aggregator.Aliases(server.URL+"/docs/x"))
+	assert.Equal(t, []string{server.URL + "/docs/"}, scraper.aggregator.Aliases(server.URL+"/docs"))
+
+	data, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "*Also available at: "+server.URL+"/docs/mirror")
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 5*time.Second, parseRetryAfter("5", now))
+	assert.Equal(t, 30*time.Second, parseRetryAfter(now.Add(30*time.Second).Format(http.TimeFormat), now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(now.Add(-time.Minute).Format(http.TimeFormat), now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("-3", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("soon", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("", now))
+}
+
+func TestHostThrottle(t *testing.T) {
+	throttle := newHostThrottle(2*time.Second, 10*time.Second)
+
+	assert.Equal(t, time.Duration(0), throttle.reserve("example.com"), "Unthrottled hosts never wait")
+
+	assert.Equal(t, 2*time.Second, throttle.backoff("example.com", 0))
+	assert.Equal(t, 4*time.Second, throttle.backoff("example.com", 0))
+	assert.Equal(t, 8*time.Second, throttle.backoff("example.com", 7*time.Second))
+	assert.Equal(t, 10*time.Second, throttle.backoff("example.com", time.Minute), "Delay is capped")
+
+	throttle.relax("example.com")
+	assert.Equal(t, 7500*time.Millisecond, throttle.hosts["example.com"].penalty)
+
+	for i := 0; i < 30; i++ {
+		throttle.relax("example.com")
+	}
+	assert.Equal(t, time.Duration(0), throttle.hosts["example.com"].penalty, "Host recovers fully")
+
+	assert.Equal(t, map[string]int{"example.com": 4}, throttle.events())
+	assert.Greater(t, throttle.reserve("example.com"), time.Duration(0), "Booked slot from the last backoff is kept")
+
+	_, ok := throttle.requeue("https://example.com/a", 1)
+	assert.True(t, ok)
+	_, ok = throttle.requeue("https://example.com/a", 1)
+	assert.False(t, ok)
+}
+
+func TestThrottledHostIsRetried(t *testing.T) {
+	var mu sync.Mutex
+	hits := make(map[string]int)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		attempt := hits[r.URL.Path]
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/docs":
+			if attempt == 1 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			_, _ = w.Write([]byte(`<html><body><main><p>Docs</p><a href="/docs/a">A</a></main></body></html>`))
+		case "/docs/a":
+			if attempt == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte(`<html><body><main><p>Page A</p></main></body></html>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		OutputFile: t.TempDir() + "/throttle.md",
+		StartURLs:  []string{server.URL + "/docs"},
+		Processing: config.ProcessingConfig{
+			MaxDepth:    2,
+			Concurrency: 1,
+		},
+		Security: config.SecurityConfig{
+			RequestTimeout:  5 * time.Second,
+			ScrapingTimeout: 30 * time.Second,
+		},
+		Throttle: config.ThrottleConfig{
+			MaxDelay:    config.DefaultThrottleMaxDelay,
+			MaxRequeues: config.DefaultThrottleMaxRequeues,
+		},
+		Monitoring: config.MonitoringConfig{
+			LogLevel: "error",
+		},
+	}
+
+	scraper, err := New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, scraper.Run())
+
+	assert.Equal(t, 2, scraper.aggregator.GetPageCount())
+	assert.Equal(t, 2, hits["/docs"])
+	assert.Equal(t, 2, hits["/docs/a"])
+	assert.Equal(t, map[string]int{strings.TrimPrefix(server.URL, "http://"): 2}, scraper.throttle.events())
+}
+
+func TestThrottledURLGivenUp(t *testing.T) {
+	var busyHits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/docs":
+			_, _ = w.Write([]byte(`<html><body><main><p>Docs</p><a href="/docs/busy">Busy</a></main></body></html>`))
+		case "/docs/busy":
+			busyHits.Add(1)
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		OutputFile: t.TempDir() + "/throttle.md",
+		StartURLs:  []string{server.URL + "/docs"},
+		Processing: config.ProcessingConfig{
+			MaxDepth:    2,
+			Concurrency: 1,
+		},
+		Throttle: config.ThrottleConfig{
+			MaxDelay:    50 * time.Millisecond,
+			MaxRequeues: 2,
+		},
+		Retry: config.RetryConfig{
+			MaxAttempts: 5,
+			BackoffBase: 10 * time.Millisecond,
+			StatusCodes: []int{http.StatusTooManyRequests},
+		},
+		Security: config.SecurityConfig{
+			RequestTimeout:  5 * time.Second,
+			ScrapingTimeout: 30 * time.Second,
+		},
+		Monitoring: config.MonitoringConfig{
+			LogLevel: "error",
+		},
+	}
+
+	scraper, err := New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, scraper.Run())
+
+	assert.Equal(t, int32(3), busyHits.Load(), "the first request and max_requeues requeues, no retries after")
+	failures := scraper.failedURLs()
+	require.Len(t, failures, 1)
+	assert.Equal(t, server.URL+"/docs/busy", failures[0].URL)
+	assert.Equal(t, http.StatusTooManyRequests, failures[0].StatusCode)
+	assert.Equal(t, 3, failures[0].Attempts)
+}
+
+func TestRetryPolicy(t *testing.T) {
+	policy := newRetryPolicy(config.RetryConfig{
+		MaxAttempts: 3,
+		BackoffBase: time.Second,
+		BackoffMax:  3 * time.Second,
+	})
+
+	assert.Equal(t, time.Second, policy.backoff(1))
+	assert.Equal(t, 2*time.Second, policy.backoff(2))
+	assert.Equal(t, 3*time.Second, policy.backoff(3), "Backoff is capped")
+
+	assert.True(t, policy.retryable(http.StatusBadGateway, nil))
+	assert.False(t, policy.retryable(http.StatusNotFound, nil))
+
+	refused := &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}
+	assert.True(t, policy.retryable(0, refused))
+	assert.Equal(t, config.NetworkErrorConnectionRefused, networkErrorKind(refused))
+	assert.Equal(t, config.NetworkErrorTimeout, networkErrorKind(context.DeadlineExceeded))
+	assert.Equal(t, "", networkErrorKind(&net.DNSError{Err: "no such host", IsNotFound: true}))
+	assert.False(t, policy.retryable(0, fmt.Errorf("something else")))
+
+	_, more := policy.next("https://example.com/a")
+	assert.True(t, more)
+	_, more = policy.next("https://example.com/a")
+	assert.True(t, more)
+	attempts, more := policy.next("https://example.com/a")
+	assert.False(t, more)
+	assert.Equal(t, 3, attempts)
+
+	onlyTimeouts := newRetryPolicy(config.RetryConfig{
+		StatusCodes:   []int{},
+		NetworkErrors: []string{config.NetworkErrorTimeout},
+	})
+	assert.False(t, onlyTimeouts.retryable(http.StatusInternalServerError, nil))
+	assert.False(t, onlyTimeouts.retryable(0, refused))
+}
+
+func TestRetryAllPages(t *testing.T) {
+	var mu sync.Mutex
+	hits := make(map[string]int)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		attempt := hits[r.URL.Path]
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/docs":
+			_, _ = w.Write([]byte(`<html><body><main><p>Docs</p>
+				<a href="/docs/flaky">Flaky</a>
+				<a href="/docs/broken">Broken</a>
+				<a href="/docs/missing">Missing</a>
+			</main></body></html>`))
+		case "/docs/flaky":
+			if attempt < 3 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			_, _ = w.Write([]byte(`<html><body><main><p>Flaky page</p></main></body></html>`))
+		case "/docs/broken":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		OutputFile: t.TempDir() + "/retry.md",
+		StartURLs:  []string{server.URL + "/docs"},
+		Retry: config.RetryConfig{
+			MaxAttempts: 3,
+			BackoffBase: 10 * time.Millisecond,
+			BackoffMax:  20 * time.Millisecond,
+		},
+		Processing: config.ProcessingConfig{
+			MaxDepth:    2,
+			Concurrency: 1,
+		},
+		Security: config.SecurityConfig{
+			RequestTimeout:  5 * time.Second,
+			ScrapingTimeout: 10 * time.Second,
+		},
+		Monitoring: config.MonitoringConfig{
+			LogLevel: "error",
+		},
+	}
+
+	scraper, err := New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, scraper.Run())
+
+	assert.Equal(t, 2, scraper.aggregator.GetPageCount(), "Flaky page should be recovered")
+	assert.Equal(t, 3, hits["/docs/flaky"])
+	assert.Equal(t, 3, hits["/docs/broken"])
+	assert.Equal(t, 1, hits["/docs/missing"], "Non-retryable statuses are not retried")
+
+	failures := scraper.failedURLs()
+	require.Len(t, failures, 2)
+	assert.Equal(t, server.URL+"/docs/broken", failures[0].URL)
+	assert.Equal(t, http.StatusBadGateway, failures[0].StatusCode)
+	assert.Equal(t, 3, failures[0].Attempts)
+	assert.Equal(t, server.URL+"/docs/missing", failures[1].URL)
+	assert.Equal(t, 1, failures[1].Attempts)
+}
+
+func TestCrawlReport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/docs":
+			_, _ = w.Write([]byte(`<html><body><main><p>Docs</p>
+				<a href="/docs/page">Page</a>
+				<a href="/docs/copy">Copy</a>
+				<a href="/docs/empty">Empty</a>
+				<a href="/docs/gone">Gone</a>
+				<a href="/docs/file.pdf">PDF</a>
+				<a href="/privacy">Privacy</a>
+				<a href="https://elsewhere.example/docs">Elsewhere</a>
+			</main></body></html>`))
+		case "/docs/page", "/docs/copy":
+			_, _ = w.Write([]byte(`<html><body><main><p>Shared content</p></main></body></html>`))
+		case "/docs/empty":
+			_, _ = w.Write([]byte(`<html><body><div>Nothing here</div></body></html>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tmp := t.TempDir()
+	cfg := &config.Config{
+		Name:           "Report Test",
+		OutputFile:     tmp + "/report.md",
+		ReportFile:     tmp + "/report.json",
+		StartURLs:      []string{server.URL + "/docs"},
+		IgnorePatterns: []string{`\.pdf$`},
+		Selectors: config.SelectorConfig{
+			Content: "main",
+		},
+		Processing: config.ProcessingConfig{
+			MaxDepth:    2,
+			Concurrency: 1,
+		},
+		Security: config.SecurityConfig{
+			// Matched against host:port
+			AllowedDomains:  []string{strings.TrimPrefix(server.URL, "http://")},
+			RequestTimeout:  5 * time.Second,
+			ScrapingTimeout: 10 * time.Second,
+		},
+		Monitoring: config.MonitoringConfig{
+			LogLevel: "error",
+		},
+	}
+
+	scraper, err := New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, scraper.Run())
+
+	data, err := os.ReadFile(cfg.ReportFile)
+	require.NoError(t, err)
+
+	var doc report.Document
+	require.NoError(t, json.Unmarshal(data, &doc))
+	assert.True(t, doc.Complete)
+
+	outcomes := make(map[string]*report.Entry)
+	for _, entry := range doc.URLs {
+		outcomes[strings.TrimPrefix(entry.URL, server.URL)] = entry
+	}
+
+	docs := outcomes["/docs"]
+	require.NotNil(t, docs)
+	assert.Equal(t, report.OutcomeConverted, docs.Outcome)
+	assert.Equal(t, http.StatusOK, docs.StatusCode)
+	assert.Equal(t, 1, docs.Depth)
+	assert.Greater(t, docs.ResponseBytes, 0)
+	assert.Greater(t, docs.MarkdownBytes, 0)
+	assert.NotNil(t, docs.FetchedAt)
+
+	page := outcomes["/docs/page"]
+	require.NotNil(t, page)
+	assert.Equal(t, report.OutcomeConverted, page.Outcome)
+	assert.Equal(t, server.URL+"/docs", page.Parent)
+	assert.Equal(t, 2, page.Depth)
+
+	assert.Equal(t, report.OutcomeDuplicate, outcomes["/docs/copy"].Outcome)
+	assert.Equal(t, report.OutcomeNoContent, outcomes["/docs/empty"].Outcome)
+	assert.Equal(t, report.OutcomeHTTPError, outcomes["/docs/gone"].Outcome)
+	assert.Equal(t, http.StatusNotFound, outcomes["/docs/gone"].StatusCode)
+	assert.Equal(t, report.OutcomeIgnored, outcomes["/docs/file.pdf"].Outcome)
+	assert.Equal(t, `\.pdf$`, outcomes["/docs/file.pdf"].Reason)
+	assert.Equal(t, report.OutcomeSkipCategory, outcomes["/privacy"].Outcome)
+	assert.Equal(t, "legal: privacy", outcomes["/privacy"].Reason)
+
+	elsewhere := outcomes["https://elsewhere.example/docs"]
+	require.NotNil(t, elsewhere)
+	assert.Equal(t, report.OutcomeDisallowedDomain, elsewhere.Outcome)
+	assert.Equal(t, server.URL+"/docs", elsewhere.Parent)
+}
+
+func TestNavigationOrder(t *testing.T) {
+	var serverURL string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := func(title string) string {
+			return `<html><head><title>` + title + `</title></head><body>
+				<nav class="sidebar"><ul>
+					<li><span>Getting Started</span><ul>
+						<li><a href="/docs/installation">Installation</a></li>
+						<li><a href="` + serverURL + `/docs/configuration/">Configuration</a></li>
+					</ul></li>
+					<li><a href="/docs/advanced">Advanced</a><ul>
+						<li><a href="/docs/advanced/plugins">Plugins</a></li>
+					</ul></li>
+					<li><a href="https://elsewhere.example/community">Community</a></li>
+				</ul></nav>
+				<main><p>` + title + ` content</p><a href="/docs/hidden">Hidden</a></main>
+			</body></html>`
+		}
+		switch r.URL.Path {
+		case "/docs":
+			_, _ = w.Write([]byte(page("Overview")))
+		case "/docs/installation", "/docs/configuration", "/docs/advanced", "/docs/advanced/plugins", "/docs/hidden":
+			title := strings.TrimPrefix(r.URL.Path, "/docs/")
+			_, _ = w.Write([]byte(`<html><head><title>` + title + `</title></head><body><main><p>` + title + ` content</p></main></body></html>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	serverURL = server.URL
+
+	tmp := t.TempDir()
+	cfg := &config.Config{
+		Name:         "Navigation Test",
+		OutputFile:   tmp + "/nav.md",
+		StartURLs:    []string{server.URL + "/docs"},
+		Canonicalize: config.CanonicalConfig{TrailingSlash: config.TrailingSlashStrip},
+		Selectors: config.SelectorConfig{
+			Content:    "main",
+			Navigation: "nav.sidebar",
+		},
+		Processing: config.ProcessingConfig{
+			MaxDepth:    3,
+			Concurrency: 1,
+			GenerateTOC: true,
+		},
+		Security: config.SecurityConfig{
+			AllowedDomains:  []string{strings.TrimPrefix(server.URL, "http://")},
+			RequestTimeout:  5 * time.Second,
+			ScrapingTimeout: 10 * time.Second,
+		},
+		Monitoring: config.MonitoringConfig{
+			LogLevel: "error",
+		},
+	}
+
+	scraper, err := New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, scraper.Run())
+
+	nav := scraper.aggregator.Navigation()
+	require.Len(t, nav, 3)
+	assert.Equal(t, "Getting Started", nav[0].Title)
+	assert.Empty(t, nav[0].URL)
+	require.Len(t, nav[0].Children, 2)
+	assert.Equal(t, server.URL+"/docs/installation", nav[0].Children[0].URL)
+	assert.Equal(t, server.URL+"/docs/configuration", nav[0].Children[1].URL, "nav URLs are canonicalized")
+	assert.Equal(t, server.URL+"/docs/advanced", nav[1].URL)
+	require.Len(t, nav[1].Children, 1)
+	assert.Equal(t, "Community", nav[2].Title)
+	assert.Empty(t, nav[2].URL, "links to other domains keep only their title")
+
+	data, err := os.ReadFile(cfg.OutputFile)
+	require.NoError(t, err)
+	output := string(data)
+
+	headings := []string{
+		"## Getting Started",
+		"### installation",
+		"### configuration",
+		"## advanced",
+		"### advanced/plugins",
+		"## Appendix",
+		"### Overview",
+		"### hidden",
+	}
+	last := -1
+	for _, heading := range headings {
+		index := strings.Index(output, heading+"\n")
+		require.NotEqual(t, -1, index, heading)
+		assert.Greater(t, index, last, heading)
+		last = index
+	}
+}
+
+
+func TestNavigationCapturedOnce(t *testing.T) {
+	var serverURL string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/docs", "/docs/", "/docs/index.html":
+			_, _ = w.Write([]byte(`<html><head><title>Overview</title>
+				<link rel="canonical" href="` + serverURL + `/docs"></head><body>
+				<nav class="sidebar"><ul>
+					<li><a href="/docs/installation">Installation</a></li>
+				</ul></nav>
+				<main><p>Overview content</p></main>
+			</body></html>`))
+		case "/docs/installation":
+			_, _ = w.Write([]byte(`<html><head><title>Installation</title></head><body><main><p>Install</p></main></body></html>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	serverURL = server.URL
+
+	cfg := &config.Config{
+		OutputFile: t.TempDir() + "/nav.md",
+		StartURLs:  []string{server.URL + "/docs", server.URL + "/docs/index.html"},
+		Selectors: config.SelectorConfig{
+			Content:    "main",
+			Navigation: "nav.sidebar",
+		},
+		Processing: config.ProcessingConfig{
+			MaxDepth:    2,
+			Concurrency: 1,
+		},
+		Security: config.SecurityConfig{
+			RequestTimeout:  5 * time.Second,
+			ScrapingTimeout: 10 * time.Second,
+		},
+		Monitoring: config.MonitoringConfig{
+			LogLevel: "error",
+		},
+	}
+
+	scraper, err := New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, scraper.Run())
+
+	nav := scraper.aggregator.Navigation()
+	require.Len(t, nav, 1, "a start page reached through an alias adds its navigation once")
+	assert.Equal(t, server.URL+"/docs/installation", nav[0].URL)
+}
+func TestFilesystemSource(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"index.html": `<html><head><title>Home</title></head><body><main><p>Home content</p>
+			<a href="guide/">Guide</a>
+			<a href="https://elsewhere.example/page">Elsewhere</a>
+			<a href="../../outside.html">Outside</a>
+		</main></body></html>`,
+		"guide/index.html": `<html><head><title>Guide</title></head><body><main><p>Guide content</p>
+			<a href="install.html">Install</a>
+		</main></body></html>`,
+		"guide/install.html": `<html><head><title>Install</title></head><body><main><p>Install content</p></main></body></html>`,
+		"orphan.htm":         `<html><head><title>Orphan</title></head><body><main><p>Orphan content</p></main></body></html>`,
+		".hidden/page.html":  `<html><body><main><p>Hidden content</p></main></body></html>`,
+		"styles.css":         `main { color: red }`,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	tmp := t.TempDir()
+	cfg := &config.Config{
+		Name:       "Offline Test",
+		OutputFile: filepath.Join(tmp, "offline.md"),
+		ReportFile: filepath.Join(tmp, "report.json"),
+		Source: config.SourceConfig{
+			Type:    config.SourceTypeFilesystem,
+			Root:    root,
+			BaseURL: "https://docs.example.com/",
+		},
+		FollowPatterns: []string{`^https://docs\.example\.com/`},
+		Selectors: config.SelectorConfig{
+			Content: "main",
+		},
+		Canonicalize: config.CanonicalConfig{TrailingSlash: config.TrailingSlashStrip},
+		Processing: config.ProcessingConfig{
+			MaxDepth:    3,
+			Concurrency: 1,
+			Delay:       5,
+		},
+		Security: config.SecurityConfig{
+			RespectRobots:   true,
+			RequestTimeout:  5 * time.Second,
+			ScrapingTimeout: 10 * time.Second,
+		},
+		Monitoring: config.MonitoringConfig{
+			LogLevel: "error",
+		},
+	}
+	require.NoError(t, cfg.SetDefaults())
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, []string{"https://docs.example.com/"}, cfg.StartURLs)
+
+	scraper, err := New(cfg)
+	require.NoError(t, err)
+
+	start := time.Now()
+	require.NoError(t, scraper.Run())
+	assert.Less(t, time.Since(start), 5*time.Second, "no politeness delay for local files")
+
+	urls := make(map[string]string)
+	for _, page := range scraper.aggregator.Pages() {
+		urls[page.URL] = page.Title
+	}
+	assert.Equal(t, map[string]string{
+		"https://docs.example.com/":                   "Home",
+		"https://docs.example.com/guide":              "Guide",
+		"https://docs.example.com/guide/install.html": "Install",
+		"https://docs.example.com/orphan.htm":         "Orphan",
+	}, urls)
+
+	data, err := os.ReadFile(cfg.ReportFile)
+	require.NoError(t, err)
+	var doc report.Document
+	require.NoError(t, json.Unmarshal(data, &doc))
+	outcomes := make(map[string]report.Outcome)
+	for _, entry := range doc.URLs {
+		outcomes[entry.URL] = entry.Outcome
+	}
+	assert.Equal(t, report.OutcomeDisallowedDomain, outcomes["https://elsewhere.example/page"])
+	assert.Equal(t, report.OutcomeConverted, outcomes["https://docs.example.com/orphan.htm"])
+}
+
+func TestFilesystemSourceRoundTrip(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "docs"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "docs", "index.html"), []byte("<html></html>"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "..", "secret.html"), []byte("secret"), 0644))
+
+	source, err := newFilesystemSource(config.SourceConfig{Root: root})
+	require.NoError(t, err)
+
+	get := func(rawURL string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+		require.NoError(t, err)
+		resp, err := source.RoundTrip(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := get("file:///docs")
+	assert.Equal(t, http.StatusMovedPermanently, resp.StatusCode)
+	assert.Equal(t, "file:///docs/", resp.Header.Get("Location"))
+
+	resp = get("file:///docs/")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/html")
+
+	assert.Equal(t, http.StatusNotFound, get("file:///missing.html").StatusCode)
+	assert.Equal(t, http.StatusNotFound, get("file:///../secret.html").StatusCode, "paths cannot leave the root")
+
+	req, err := http.NewRequest(http.MethodGet, "https://example.com/", nil)
+	require.NoError(t, err)
+	_, err = source.RoundTrip(req)
+	assert.Error(t, err)
+}
+
+func TestWARCArchiveReplay(t *testing.T) {
+	var requests int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		switch r.URL.Path {
+		case "/robots.txt":
+			fmt.Fprint(w, "User-agent: *\nAllow: /\n")
+		case "/docs":
+			fmt.Fprint(w, `<html><head><title>Home</title></head><body><main><p>Home content</p>
+				<a href="/docs/moved">Moved</a><a href="/docs/missing">Missing</a></main></body></html>`)
+		case "/docs/moved":
+			http.Redirect(w, r, "/docs/guide", http.StatusMovedPermanently)
+		case "/docs/guide":
+			fmt.Fprint(w, `<html><head><title>Guide</title></head><body><main><p>Guide content</p></main></body></html>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	tmp := t.TempDir()
+	newConfig := func(output string) *config.Config {
+		cfg := &config.Config{
+			Name:           "WARC Test",
+			BaseURL:        server.URL + "/docs",
+			OutputFile:     filepath.Join(tmp, output),
+			StartURLs:      []string{server.URL + "/docs"},
+			FollowPatterns: []string{"^" + regexp.QuoteMeta(server.URL) + "/docs"},
+			Selectors:      config.SelectorConfig{Content: "main"},
+			Processing:     config.ProcessingConfig{MaxDepth: 3, Concurrency: 1},
+			Security: config.SecurityConfig{
+				RespectRobots:   true,
+				RequestTimeout:  5 * time.Second,
+				ScrapingTimeout: 10 * time.Second,
+			},
+			Retry:      config.RetryConfig{MaxAttempts: 1},
+			Monitoring: config.MonitoringConfig{LogLevel: "error"},
+		}
+		require.NoError(t, cfg.SetDefaults())
+		return cfg
+	}
+
+	cfg := newConfig("live.md")
+	cfg.WARCFile = filepath.Join(tmp, "crawl.warc.gz")
+	scraper, err := New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, scraper.Run())
+	server.Close()
+	fetched := atomic.LoadInt64(&requests)
+	assert.Greater(t, fetched, int64(3))
+
+	cfg = newConfig("replay.md")
+	cfg.Source = config.SourceConfig{Type: config.SourceTypeWARC, Path: filepath.Join(tmp, "crawl.warc.gz")}
+	require.NoError(t, cfg.Validate())
+	replayed, err := New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, replayed.Run())
+	assert.Equal(t, fetched, atomic.LoadInt64(&requests), "replay must not touch the network")
+
+	live, err := os.ReadFile(filepath.Join(tmp, "live.md"))
+	require.NoError(t, err)
+	replay, err := os.ReadFile(filepath.Join(tmp, "replay.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(live), "Guide content")
+	assert.Equal(t, string(live), string(replay))
+}
+
+func TestRequestSettings(t *testing.T) {
+	type seen struct {
+		auth, team, cookie string
+	}
+	var mu sync.Mutex
+	requests := make(map[string]seen)
+	record := func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.Host+r.URL.Path] = seen{r.Header.Get("Authorization"), r.Header.Get("X-Team"), r.Header.Get("Cookie")}
+		mu.Unlock()
+	}
+
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		record(w, r)
+		fmt.Fprint(w, "ok")
+	}))
+	defer other.Close()
+	otherURL := strings.Replace(other.URL, "127.0.0.1", "localhost", 1)
+
+	docs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		record(w, r)
+		if r.URL.Path == "/leave" {
+			http.Redirect(w, r, otherURL+"/landing", http.StatusFound)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer docs.Close()
+	docsHost := strings.TrimPrefix(docs.URL, "http://")
+
+	tmp := t.TempDir()
+	cookiesFile := filepath.Join(tmp, "cookies.txt")
+	require.NoError(t, os.WriteFile(cookiesFile, []byte(strings.Join([]string{
+		"# Netscape HTTP Cookie File",
+		"#HttpOnly_127.0.0.1\tFALSE\t/\tFALSE\t0\tsession\tcookie-secret",
+		"127.0.0.1\tFALSE\t/\tFALSE\t1\texpired\tgone",
+		"",
+	}, "\n")), 0600))
+	tokenFile := filepath.Join(tmp, "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("token-secret\n"), 0600))
+
+	cfg := &config.Config{
+		Name:       "Auth Test",
+		OutputFile: filepath.Join(tmp, "out.md"),
+		WARCFile:   filepath.Join(tmp, "crawl.warc"),
+		Requests: config.RequestsConfig{
+			CookiesFile: cookiesFile,
+			Domains: []config.DomainRequestConfig{{
+				Domain:      "127.0.0.1",
+				Headers:     map[string]string{"X-Team": "docs"},
+				BearerToken: &config.SecretRef{File: tokenFile},
+			}},
+		},
+		Security: config.SecurityConfig{RequestTimeout: 5 * time.Second},
+	}
+
+	s, err := New(cfg)
+	require.NoError(t, err)
+
+	resp, err := s.httpClient.Get(docs.URL + "/leave")
+	require.NoError(t, err)
+	resp.Body.Close()
+	s.closeArchive()
+
+	assert.Equal(t, seen{"Bearer token-secret", "docs", "session=cookie-secret"}, requests[docsHost+"/leave"])
+	assert.Equal(t, seen{}, requests[strings.TrimPrefix(otherURL, "http://")+"/landing"], "credentials stay on their domain")
+
+	archive, err := os.ReadFile(cfg.WARCFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(archive), "X-Team: docs")
+	assert.NotContains(t, string(archive), "token-secret")
+	assert.NotContains(t, string(archive), "cookie-secret")
+
+	t.Setenv("MARKDOCIFY_TEST_PASSWORD", "")
+	cfg.WARCFile = ""
+	cfg.Requests.Domains[0].BearerToken = nil
+	cfg.Requests.Domains[0].BasicAuth = &config.BasicAuthConfig{
+		Username: "docs",
+		Password: config.SecretRef{Env: "MARKDOCIFY_TEST_PASSWORD"},
+	}
+	_, err = New(cfg)
+	assert.ErrorContains(t, err, "environment variable MARKDOCIFY_TEST_PASSWORD is not set")
+
+	t.Setenv("MARKDOCIFY_TEST_PASSWORD", "hunter2")
+	s, err = New(cfg)
+	require.NoError(t, err)
+	resp, err = s.httpClient.Get(docs.URL + "/basic")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "Basic ZG9jczpodW50ZXIy", requests[docsHost+"/basic"].auth)
+}
+
+func TestParseCookieLine(t *testing.T) {
+	cookie, u, err := parseCookieLine(".example.com\tTRUE\t/docs\tTRUE\t2000000000\tsid\tvalue")
+	require.NoError(t, err)
+	assert.Equal(t, "example.com", cookie.Domain)
+	assert.Equal(t, "/docs", cookie.Path)
+	assert.True(t, cookie.Secure)
+	assert.Equal(t, int64(2000000000), cookie.Expires.Unix())
+	assert.Equal(t, "https://example.com/docs", u.String())
+
+	cookie, _, err = parseCookieLine("# comment")
+	require.NoError(t, err)
+	assert.Nil(t, cookie)
+
+	_, _, err = parseCookieLine("example.com\tFALSE\t/\tFALSE\tsoon\tsid\tsecret-value")
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret-value")
+}
+
+// writeClientCert writes a self-signed client certificate and its key as
+// PEM files and returns their paths and the certificate.
+func writeClientCert(t *testing.T, dir string) (string, string, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "markdocify test client"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile := filepath.Join(dir, "client.pem")
+	keyFile := filepath.Join(dir, "client-key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile, cert
+}
+
+func TestNetworkTransport(t *testing.T) {
+	tmp := t.TempDir()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	get := func(network config.NetworkConfig, rawURL string) (string, error) {
+		transport, err := newNetworkTransport(network, logger)
+		require.NoError(t, err)
+		client := &http.Client{Transport: transport, Timeout: 5 * time.Second}
+		resp, err := client.Get(rawURL)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	clientCert, clientKey, cert := writeClientCert(t, tmp)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(cert)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "private docs")
+	}))
+	server.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+		MaxVersion: tls.VersionTLS12,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	caFile := filepath.Join(tmp, "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600))
+
+	_, err := get(config.NetworkConfig{TLSMinVersion: "1.2"}, server.URL)
+	assert.Error(t, err, "the private CA is not trusted by default")
+
+	_, err = get(config.NetworkConfig{CAFiles: []string{caFile}}, server.URL)
+	assert.Error(t, err, "the server requires a client certificate")
+
+	network := config.NetworkConfig{CAFiles: []string{caFile}, ClientCert: clientCert, ClientKey: clientKey}
+	body, err := get(network, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "private docs", body)
+
+	network.TLSMinVersion = "1.3"
+	_, err = get(network, server.URL)
+	assert.Error(t, err, "the server only speaks TLS 1.2")
+
+	_, err = newNetworkTransport(config.NetworkConfig{CAFiles: []string{clientKey}}, logger)
+	assert.ErrorContains(t, err, "no PEM certificates found")
+
+	transport, err := newNetworkTransport(config.NetworkConfig{}, logger)
+	require.NoError(t, err)
+	assert.Nil(t, transport, "the default transport is kept")
+}
+
+func TestNetworkProxy(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Proxy-Authorization") != "Basic "+base64.StdEncoding.EncodeToString([]byte("crawler:proxy-secret")) {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		fmt.Fprintf(w, "proxied %s", r.URL)
+	}))
+	defer proxy.Close()
+
+	t.Setenv("MARKDOCIFY_TEST_PROXY_PASSWORD", "proxy-secret")
+	var logs bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&logs)
+
+	transport, err := newNetworkTransport(config.NetworkConfig{
+		Proxy: config.ProxyConfig{
+			HTTP:     proxy.URL,
+			HTTPS:    "http://https-proxy.example:3128",
+			NoProxy:  []string{".internal.example"},
+			Username: "crawler",
+			Password: &config.SecretRef{Env: "MARKDOCIFY_TEST_PROXY_PASSWORD"},
+		},
+	}, logger)
+	require.NoError(t, err)
+
+	client := &http.Client{Transport: transport, Timeout: 5 * time.Second}
+	resp, err := client.Get("http://docs.example/page")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "proxied http://docs.example/page", string(body))
+
+	proxyFor := func(rawURL string) string {
+		req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+		require.NoError(t, err)
+		u, err := transport.(*http.Transport).Proxy(req)
+		require.NoError(t, err)
+		if u == nil {
+			return ""
+		}
+		return u.Host
+	}
+	assert.Equal(t, "https-proxy.example:3128", proxyFor("https://docs.example/"))
+	assert.Equal(t, "", proxyFor("https://wiki.internal.example/"), "no_proxy hosts are reached directly")
+
+	assert.Contains(t, logs.String(), "Using custom network settings")
+	assert.NotContains(t, logs.String(), "proxy-secret")
+}
+
+func TestConcurrentCrawlIsDeterministic(t *testing.T) {
+	var inFlight, maxInFlight, requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			peak := maxInFlight.Load()
+			if n <= peak || maxInFlight.CompareAndSwap(peak, n) {
+				break
+			}
+		}
+		// Uneven response times shuffle the order requests complete in
+		time.Sleep(time.Duration(requests.Add(7)%13) * time.Millisecond)
+
+		title, body := r.URL.Path, ""
+		switch {
+		case r.URL.Path == "/":
+			for i := 0; i < 8; i++ {
+				body += fmt.Sprintf(`<a href="/s%d">Section %d</a>`, i, i)
+			}
+		case regexp.MustCompile(`^/s\d$`).MatchString(r.URL.Path):
+			next := fmt.Sprintf("/s%d/a", (int(r.URL.Path[2]-'0')+1)%8)
+			body = fmt.Sprintf(`<p>%s</p><a href="%s/a">A</a><a href="%s/b">B</a><a href="%s">Next</a>`,
+				r.URL.Path, r.URL.Path, r.URL.Path, next)
+		case strings.HasSuffix(r.URL.Path, "/b"):
+			// Every b page has the same content, only the first is kept
+			title, body = "Shared", `<p>Shared content</p>`
+		default:
+			body = "<p>" + r.URL.Path + "</p>"
+		}
+		_, _ = fmt.Fprintf(w, `<html><body><main><h1>%s</h1>%s</main></body></html>`, title, body)
+	}))
+	defer server.Close()
+
+	crawl := func(name string, concurrency, workers int) (string, *Scraper) {
+		cfg := &config.Config{
+			Name:       "Determinism Test",
+			OutputFile: filepath.Join(t.TempDir(), name+".md"),
+			StartURLs:  []string{server.URL + "/"},
+			Processing: config.ProcessingConfig{
+				MaxDepth:    3,
+				Concurrency: concurrency,
+				Workers:     workers,
+				GenerateTOC: true,
+			},
+			Security: config.SecurityConfig{
+				RequestTimeout:  5 * time.Second,
+				ScrapingTimeout: 20 * time.Second,
+			},
+			Monitoring: config.MonitoringConfig{
+				LogLevel: "error",
+			},
+		}
+		scraper, err := New(cfg)
+		require.NoError(t, err)
+		require.NoError(t, scraper.Run())
+
+		data, err := os.ReadFile(cfg.OutputFile)
+		require.NoError(t, err)
+		return string(data), scraper
+	}
+
+	sequential, _ := crawl("sequential", 1, 1)
+	assert.Equal(t, int64(1), maxInFlight.Load(), "one request at a time per host")
+
+	maxInFlight.Store(0)
+	first, scraper := crawl("first", 8, 4)
+	assert.Greater(t, maxInFlight.Load(), int64(1), "pages are fetched concurrently")
+	second, _ := crawl("second", 8, 4)
+
+	assert.Equal(t, sequential, first)
+	assert.Equal(t, first, second)
+	// 1 home page, 8 sections, 8 a pages and the first b page
+	assert.Equal(t, 18, scraper.aggregator.GetPageCount())
+	for _, page := range scraper.aggregator.Pages() {
+		want := strings.Count(strings.TrimPrefix(page.URL, server.URL), "/")
+		if page.URL == server.URL+"/" {
+			want = 0
+		}
+		assert.Equal(t, want+1, page.Depth, page.URL)
+	}
+	assert.Equal(t, []string{server.URL + "/s1/b", server.URL + "/s2/b", server.URL + "/s3/b",
+		server.URL + "/s4/b", server.URL + "/s5/b", server.URL + "/s6/b", server.URL + "/s7/b"},
+		scraper.aggregator.Aliases(server.URL+"/s0/b"))
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestHostLimiter(t *testing.T) {
+	var inFlight, maxInFlight int32
+	limiter := newHostLimiter()
+	limiter.next = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+	})
+
+	assert.True(t, limiter.limit("example.com", 2, 0))
+	assert.False(t, limiter.limit("example.com", 5, time.Second), "First registration wins")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+			resp, err := limiter.RoundTrip(req)
+			if assert.NoError(t, err) {
+				resp.Body.Close()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), maxInFlight)
+
+	// A request waiting for a slot gives up when its context ends
+	held, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+	first, err := limiter.RoundTrip(held)
+	require.NoError(t, err)
+	second, err := limiter.RoundTrip(held)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	waiting, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.com/", nil)
+	_, err = limiter.RoundTrip(waiting)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	first.Body.Close()
+	second.Body.Close()
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+	resp, err := limiter.RoundTrip(req)
+	require.NoError(t, err, "Closing the bodies frees the slots")
+	resp.Body.Close()
+}
+
+func TestInterruptedCrawlWritesPartialOutput(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			_, _ = w.Write([]byte(`<html><body><main><h1>Home</h1>
+				<a href="/fast">Fast</a>
+				<a href="/slow">Slow</a>
+			</main></body></html>`))
+		case "/slow":
+			<-release
+			fallthrough
+		default:
+			_, _ = fmt.Fprintf(w, `<html><body><main><h1>Page %s</h1><p>Content of %s</p></main></body></html>`, r.URL.Path, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		cancel  bool
+		wantErr error
+		banner  string
+	}{
+		{"timeout", 500 * time.Millisecond, false, context.DeadlineExceeded, "The scraping timeout was reached"},
+		{"cancelled", 10 * time.Second, true, context.Canceled, "The crawl was cancelled"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputFile := t.TempDir() + "/partial.md"
+			scraper, err := New(&config.Config{
+				Name:       "Partial",
+				OutputFile: outputFile,
+				StartURLs:  []string{server.URL + "/"},
+				Processing: config.ProcessingConfig{
+					MaxDepth:    2,
+					Concurrency: 2,
+				},
+				Output: config.OutputConfig{
+					IncludeMetadata: true,
+					WritePartial:    true,
+				},
+				Security: config.SecurityConfig{
+					RequestTimeout:  30 * time.Second,
+					ScrapingTimeout: tt.timeout,
+				},
+				Monitoring: config.MonitoringConfig{
+					LogLevel: "error",
+				},
+			})
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				time.AfterFunc(500*time.Millisecond, cancel)
+			}
+
+			start := time.Now()
+			err = scraper.RunWithContext(ctx)
+			require.ErrorIs(t, err, tt.wantErr)
+			assert.Less(t, time.Since(start), 5*time.Second, "Requests in flight are aborted")
+
+			data, err := os.ReadFile(outputFile)
+			require.NoError(t, err)
+			output := string(data)
+			assert.Contains(t, output, "> **Incomplete:** "+tt.banner+". 1 queued URLs were not visited.")
+			assert.Contains(t, output, "Content of /fast")
+			assert.NotContains(t, output, "Content of /slow")
+			assert.Contains(t, output, "## Unvisited URLs\n\n- "+server.URL+"/slow\n")
+		})
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/docs":
+			_, _ = w.Write([]byte(`<html><body><main><h1>Docs</h1>
+				<a href="/docs/guide">Guide</a>
+				<a href="/docs/file.pdf">PDF</a>
+				<a href="/blog/post">Blog</a>
+			</main></body></html>`))
+		case "/docs/guide":
+			_, _ = w.Write([]byte(`<html><body><main><h1>Guide</h1>
+				<a href="/docs/guide/deep">Deep</a>
+			</main></body></html>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tmp := t.TempDir()
+	cfg := &config.Config{
+		Name:           "Discover Test",
+		OutputFile:     tmp + "/never.md",
+		StartURLs:      []string{server.URL + "/docs"},
+		FollowPatterns: []string{`/docs`},
+		IgnorePatterns: []string{`\.pdf$`},
+		Processing: config.ProcessingConfig{
+			MaxDepth:    2,
+			Concurrency: 1,
+		},
+		Security: config.SecurityConfig{
+			RequestTimeout:  5 * time.Second,
+			ScrapingTimeout: 10 * time.Second,
+		},
+		Monitoring: config.MonitoringConfig{
+			LogLevel: "error",
+		},
+	}
+
+	scraper, err := New(cfg)
+	require.NoError(t, err)
+	doc, err := scraper.Discover(context.Background())
+	require.NoError(t, err)
+	assert.True(t, doc.Complete)
+	assert.NoFileExists(t, cfg.OutputFile)
+	assert.Equal(t, 0, scraper.aggregator.GetPageCount())
+
+	entries := make(map[string]*report.Entry)
+	for _, entry := range doc.URLs {
+		entries[strings.TrimPrefix(entry.URL, server.URL)] = entry
+	}
+	assert.Equal(t, report.OutcomeDiscovered, entries["/docs"].Outcome)
+	assert.Equal(t, "start URL", entries["/docs"].Rule)
+	assert.Equal(t, report.OutcomeDiscovered, entries["/docs/guide"].Outcome)
+	assert.Equal(t, "follow pattern: /docs", entries["/docs/guide"].Rule)
+	assert.Equal(t, 2, entries["/docs/guide"].Depth)
+	assert.Equal(t, report.OutcomeIgnored, entries["/docs/file.pdf"].Outcome)
+	assert.Equal(t, report.OutcomeNotFollowed, entries["/blog/post"].Outcome)
+	assert.Equal(t, report.OutcomeMaxDepth, entries["/docs/guide/deep"].Outcome)
+	assert.Equal(t, "max_depth 2", entries["/docs/guide/deep"].Reason)
+
+	tree := doc.Tree()
+	require.Len(t, tree, 1)
+	assert.Len(t, tree[0].Children, 3)
+}
+
+func TestImageModes(t *testing.T) {
+	png := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{1}, 64)...)
+	var logoRequests atomic.Int64
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			_, _ = w.Write([]byte(`<html><body><main><h1>Home</h1>
+				<p><img src="/img/logo.png" alt="Logo"></p>
+				<p><img src="img/copy.png" alt="Copy"></p>
+				<p><img src="/img/big.png" alt="Big"></p>
+				<a href="/other">Other</a>
+			</main></body></html>`))
+		case "/other":
+			_, _ = w.Write([]byte(`<html><body><main><h1>Other</h1><p><img src="/img/logo.png" alt="Logo"></p></main></body></html>`))
+		case "/img/logo.png":
+			logoRequests.Add(1)
+			_, _ = w.Write(png)
+		case "/img/copy.png":
+			_, _ = w.Write(png)
+		case "/img/big.png":
+			_, _ = w.Write(append(png, bytes.Repeat([]byte{2}, 4096)...))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	run := func(t *testing.T, mode string) (string, string) {
+		logoRequests.Store(0)
+		dir := t.TempDir()
+		scraper, err := New(&config.Config{
+			Name:       "Images",
+			OutputFile: filepath.Join(dir, "docs.md"),
+			StartURLs:  []string{server.URL + "/"},
+			Processing: config.ProcessingConfig{
+				MaxDepth:    2,
+				Concurrency: 2,
+			},
+			Output: config.OutputConfig{
+				PreserveImages: true,
+				Images:         mode,
+			},
+			Security: config.SecurityConfig{
+				MaxFileSizeBytes: 2048,
+				RequestTimeout:   5 * time.Second,
+				ScrapingTimeout:  10 * time.Second,
+			},
+			Monitoring: config.MonitoringConfig{
+				LogLevel: "error",
+			},
+		})
+		require.NoError(t, err)
+		require.NoError(t, scraper.Run())
+
+		content, err := os.ReadFile(filepath.Join(dir, "docs.md"))
+		require.NoError(t, err)
+		return string(content), dir
+	}
+
+	t.Run("download", func(t *testing.T) {
+		output, dir := run(t, config.ImagesDownload)
+
+		files, err := os.ReadDir(filepath.Join(dir, "assets"))
+		require.NoError(t, err)
+		require.Len(t, files, 1, "identical images are saved once")
+		asset := "assets/" + files[0].Name()
+		assert.True(t, strings.HasSuffix(asset, ".png"))
+
+		assert.Equal(t, 3, strings.Count(output, "]("+asset+")"))
+		assert.Contains(t, output, "![Big]("+server.URL+"/img/big.png)")
+		assert.Equal(t, int64(1), logoRequests.Load(), "each image URL is fetched once")
+	})
+
+	t.Run("data_uri", func(t *testing.T) {
+		output, dir := run(t, config.ImagesDataURI)
+
+		dataURI := "data:image/png;base64," + base64.StdEncoding.EncodeToString(png)
+		assert.Contains(t, output, "![Logo]("+dataURI+")")
+		assert.Contains(t, output, "![Copy]("+dataURI+")")
+		assert.Contains(t, output, "![Big]("+server.URL+"/img/big.png)")
+		assert.NoDirExists(t, filepath.Join(dir, "assets"))
+	})
+}
+
+func TestInternalLinks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			_, _ = w.Write([]byte(`<html><body><main><h1>Home</h1>
+				<p>Read the <a href="guide/?utm_source=home#first-steps">guide</a>
+				or the <a href="private/notes">notes</a>.</p>
+			</main></body></html>`))
+		case "/guide":
+			_, _ = w.Write([]byte(`<html><body><main><h1>Guide</h1><h2 id="first-steps">First steps</h2><p>Go <a href="/">home</a>.</p></main></body></html>`))
+		default:
+			_, _ = w.Write([]byte(`<html><body><main><h1>Private</h1></main></body></html>`))
+		}
+	}))
+	defer server.Close()
+
+	outputFile := t.TempDir() + "/links.md"
+	scraper, err := New(&config.Config{
+		Name:           "Links",
+		OutputFile:     outputFile,
+		StartURLs:      []string{server.URL + "/"},
+		IgnorePatterns: []string{"/private"},
+		Selectors: config.SelectorConfig{
+			Title: "h1",
+		},
+		Canonicalize: config.CanonicalConfig{
+			DropQueryParams: config.DefaultDropQueryParams,
+			TrailingSlash:   config.TrailingSlashStrip,
+		},
+		Processing: config.ProcessingConfig{
+			MaxDepth:    2,
+			Concurrency: 1,
+		},
+		Security: config.SecurityConfig{
+			RequestTimeout:  5 * time.Second,
+			ScrapingTimeout: 10 * time.Second,
+		},
+		Monitoring: config.MonitoringConfig{
+			LogLevel: "error",
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, scraper.Run())
+
+	content, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	output := string(content)
+
+	assert.Contains(t, output, "[guide](#first-steps)")
+	assert.Contains(t, output, "[home](#home)")
+	assert.Contains(t, output, "[notes]("+server.URL+"/private/notes)")
+
+	internal, external := scraper.aggregator.LinkStats()
+	assert.Equal(t, 2, internal)
+	assert.Equal(t, 1, external)
+}
+
+func TestInternalLinksFromDirectoryPages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/docs/guide", "/docs/install":
+			http.Redirect(w, r, r.URL.Path+"/", http.StatusMovedPermanently)
+		case "/docs/guide/":
+			_, _ = w.Write([]byte(`<html><body><main><h1>Guide</h1>
+				<p>First <a href="../install/">install</a>, then read the <a href="setup.html">setup</a>.</p>
+			</main></body></html>`))
+		case "/docs/install/":
+			_, _ = w.Write([]byte(`<html><body><main><h1>Install</h1><p>Steps</p></main></body></html>`))
+		case "/docs/guide/setup.html":
+			_, _ = w.Write([]byte(`<html><head><base href="/docs/reference/"></head><body><main><h1>Setup</h1>
+				<p>See the <a href="options">options</a>.</p>
+			</main></body></html>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	outputFile := t.TempDir() + "/links.md"
+	scraper, err := New(&config.Config{
+		Name:       "Links",
+		OutputFile: outputFile,
+		StartURLs:  []string{server.URL + "/docs/guide"},
+		Selectors: config.SelectorConfig{
+			Title: "h1",
+		},
+		Canonicalize: config.CanonicalConfig{
+			TrailingSlash: config.TrailingSlashStrip,
+		},
+		Processing: config.ProcessingConfig{
+			MaxDepth:    2,
+			Concurrency: 1,
+		},
+		Security: config.SecurityConfig{
+			RequestTimeout:  5 * time.Second,
+			ScrapingTimeout: 10 * time.Second,
+		},
+		Monitoring: config.MonitoringConfig{
+			LogLevel: "error",
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, scraper.Run())
+
+	content, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	output := string(content)
+
+	assert.Contains(t, output, "[install](#install)")
+	assert.Contains(t, output, "[setup](#setup)")
+	assert.Contains(t, output, "[options]("+server.URL+"/docs/reference/options)", "<base href> is honored")
+}
@@ -0,0 +1,197 @@
+package scraper
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vladkampov/markdocify/internal/report"
+)
+
+// MaxSitemapNesting bounds how deep sitemap indexes may reference other indexes.
+const MaxSitemapNesting = 5
+
+// sitemapDocument covers both <urlset> sitemaps and <sitemapindex> indexes.
+type sitemapDocument struct {
+	XMLName  xml.Name     `xml:""`
+	URLs     []sitemapLoc `xml:"url"`
+	Sitemaps []sitemapLoc `xml:"sitemap"`
+}
+
+type sitemapLoc struct {
+	Loc string `xml:"loc"`
+}
+
+// sitemapURLs collects page URLs from the configured and discovered sitemaps,
+// filtered through the same rules used for link following.
+func (s *Scraper) sitemapURLs(ctx context.Context) []string {
+	sources := append([]string{}, s.config.Sitemaps.URLs...)
+	if s.config.Sitemaps.Discover {
+		sources = append(sources, s.discoverSitemaps()...)
+	}
+
+	seenSitemaps := make(map[string]bool)
+	seenPages := make(map[string]bool)
+	var pages []string
+
+	var walk func(sitemapURL string, nesting int)
+	walk = func(sitemapURL string, nesting int) {
+		if ctx.Err() != nil || seenSitemaps[sitemapURL] {
+			return
+		}
+		seenSitemaps[sitemapURL] = true
+
+		if nesting > MaxSitemapNesting {
+			s.logger.WithField("sitemap", sitemapURL).Warn("Sitemap index nesting too deep, skipping")
+			return
+		}
+
+		doc, err := s.fetchSitemap(ctx, sitemapURL)
+		if err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"sitemap": sitemapURL,
+				"error":   err.Error(),
+			}).Warn("Failed to read sitemap")
+			return
+		}
+
+		for _, child := range doc.Sitemaps {
+			if loc := strings.TrimSpace(child.Loc); loc != "" {
+				walk(loc, nesting+1)
+			}
+		}
+
+		accepted := 0
+		for _, entry := range doc.URLs {
+			loc := strings.TrimSpace(entry.Loc)
+			if loc == "" {
+				continue
+			}
+			loc = s.canonicalURL(loc)
+			if seenPages[loc] {
+				continue
+			}
+			seenPages[loc] = true
+
+			if follow, outcome, reason := s.followDecision(loc); !follow {
+				s.trackSkip(loc, sitemapURL, 1, outcome, reason)
+				continue
+			}
+			if !s.isAllowedDomain(loc) {
+				s.trackSkip(loc, sitemapURL, 1, report.OutcomeDisallowedDomain, "")
+				continue
+			}
+			pages = append(pages, loc)
+			s.trackQueued(loc, sitemapURL, 1, "sitemap: "+sitemapURL)
+			accepted++
+		}
+
+		s.logger.WithFields(logrus.Fields{
+			"sitemap":  sitemapURL,
+			"entries":  len(doc.URLs),
+			"accepted": accepted,
+			"indexes":  len(doc.Sitemaps),
+		}).Info("Read sitemap")
+	}
+
+	for _, source := range sources {
+		walk(source, 0)
+	}
+
+	return pages
+}
+
+// discoverSitemaps returns the sitemaps advertised in robots.txt of the
+// base URL and start URL hosts.
+func (s *Scraper) discoverSitemaps() []string {
+	seenHosts := make(map[string]bool)
+	var sitemaps []string
+
+	for _, rawURL := range append([]string{s.config.BaseURL}, s.config.StartURLs...) {
+		u, err := url.Parse(rawURL)
+		if err != nil || u.Host == "" || seenHosts[u.Host] {
+			continue
+		}
+		seenHosts[u.Host] = true
+
+		data, err := s.robots.get(u)
+		if err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"host":  u.Host,
+				"error": err.Error(),
+			}).Warn("Failed to load robots.txt for sitemap discovery")
+			continue
+		}
+
+		if len(data.Sitemaps) > 0 {
+			s.logger.WithFields(logrus.Fields{
+				"host":     u.Host,
+				"sitemaps": len(data.Sitemaps),
+			}).Info("Discovered sitemaps in robots.txt")
+		}
+		sitemaps = append(sitemaps, data.Sitemaps...)
+	}
+
+	return sitemaps
+}
+
+func (s *Scraper) fetchSitemap(ctx context.Context, sitemapURL string) (*sitemapDocument, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sitemapURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("User-Agent", s.getUserAgent())
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sitemap: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var body io.Reader = resp.Body
+	if limit := s.config.Security.MaxFileSizeBytes; limit > 0 {
+		body = io.LimitReader(body, limit)
+	}
+
+	return parseSitemap(body)
+}
+
+// parseSitemap decodes a sitemap or sitemap index, transparently
+// decompressing gzipped content.
+func parseSitemap(r io.Reader) (*sitemapDocument, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open gzipped sitemap: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	var doc sitemapDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse sitemap XML: %w", err)
+	}
+
+	switch doc.XMLName.Local {
+	case "urlset", "sitemapindex":
+		return &doc, nil
+	default:
+		return nil, fmt.Errorf("unexpected sitemap root element <%s>", doc.XMLName.Local)
+	}
+}
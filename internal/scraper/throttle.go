@@ -0,0 +1,350 @@
+package scraper
+
+import (
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// minThrottleDelay is the first backoff step for hosts crawled without
+	// a configured delay.
+	minThrottleDelay = time.Second
+	// throttleRecovery is the share of the extra delay kept after each
+	// successful response from a throttled host.
+	throttleRecovery = 0.75
+	// minThrottlePenalty is the extra delay below which a host counts as
+	// fully recovered.
+	minThrottlePenalty = 100 * time.Millisecond
+)
+
+// hostThrottle adds an adaptive delay on top of the configured rate limit
+// for hosts that answer 429 or 503. The delay doubles on every throttled
+// response, honoring Retry-After, and shrinks again as requests succeed.
+type hostThrottle struct {
+	baseDelay time.Duration
+	maxDelay  time.Duration
+
+	mu       sync.Mutex
+	hosts    map[string]*throttleState
+	requeues map[string]int
+}
+
+type throttleState struct {
+	penalty time.Duration
+	next    time.Time
+	events  int
+}
+
+func newHostThrottle(baseDelay, maxDelay time.Duration) *hostThrottle {
+	return &hostThrottle{
+		baseDelay: baseDelay,
+		maxDelay:  maxDelay,
+		hosts:     make(map[string]*throttleState),
+		requeues:  make(map[string]int),
+	}
+}
+
+// reserve returns how long a request to host has to wait and books the
+// slot after it for the next request.
+func (t *hostThrottle) reserve(host string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, ok := t.hosts[host]
+	if !ok {
+		return 0
+	}
+
+	now := time.Now()
+	start := state.next
+	if start.Before(now) {
+		start = now
+	}
+	state.next = start.Add(state.penalty)
+	return start.Sub(now)
+}
+
+// backoff records a throttled response from host and returns the new
+// extra delay.
+func (t *hostThrottle) backoff(host string, retryAfter time.Duration) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, ok := t.hosts[host]
+	if !ok {
+		state = &throttleState{}
+		t.hosts[host] = state
+	}
+
+	step := t.baseDelay
+	if step < minThrottleDelay {
+		step = minThrottleDelay
+	}
+	state.penalty *= 2
+	if state.penalty < step {
+		state.penalty = step
+	}
+	if retryAfter > state.penalty {
+		state.penalty = retryAfter
+	}
+	if state.penalty > t.maxDelay {
+		state.penalty = t.maxDelay
+	}
+
+	state.next = time.Now().Add(state.penalty)
+	state.events++
+	return state.penalty
+}
+
+// relax shrinks the extra delay of host after a successful response.
+func (t *hostThrottle) relax(host string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, ok := t.hosts[host]
+	if !ok || state.penalty == 0 {
+		return
+	}
+	state.penalty = time.Duration(float64(state.penalty) * throttleRecovery)
+	if state.penalty < minThrottlePenalty {
+		state.penalty = 0
+	}
+}
+
+// requeue counts another attempt for url and reports whether it is still
+// within max.
+func (t *hostThrottle) requeue(url string, max int) (int, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.requeues[url] >= max {
+		return t.requeues[url], false
+	}
+	t.requeues[url]++
+	return t.requeues[url], true
+}
+
+// events returns the number of throttled responses per host.
+func (t *hostThrottle) events() map[string]int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	events := make(map[string]int, len(t.hosts))
+	for host, state := range t.hosts {
+		events[host] = state.events
+	}
+	return events
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an
+// HTTP date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+func isThrottled(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable
+}
+
+// hostLimiter is the per-host rate limit. It allows a host at most
+// parallelism requests at a time and keeps each request's slot taken for
+// delay after its response has been read. Waiting for a slot ends when the
+// request's context is cancelled, so queued requests never hold up a
+// cancelled crawl. Hosts without a registered limit are not limited.
+type hostLimiter struct {
+	next http.RoundTripper
+
+	mu    sync.Mutex
+	hosts map[string]*hostSlots
+}
+
+type hostSlots struct {
+	slots chan struct{}
+	delay time.Duration
+}
+
+func newHostLimiter() *hostLimiter {
+	return &hostLimiter{hosts: make(map[string]*hostSlots)}
+}
+
+// limit registers the limit of host. Only the first registration per host
+// takes effect; it reports whether this one did.
+func (l *hostLimiter) limit(host string, parallelism int, delay time.Duration) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, registered := l.hosts[host]; registered {
+		return false
+	}
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	l.hosts[host] = &hostSlots{slots: make(chan struct{}, parallelism), delay: delay}
+	return true
+}
+
+func (l *hostLimiter) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := l.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	l.mu.Lock()
+	host := l.hosts[req.URL.Host]
+	l.mu.Unlock()
+	if host == nil {
+		return next.RoundTrip(req)
+	}
+
+	select {
+	case host.slots <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		host.release()
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: host.release}
+	return resp, nil
+}
+
+// release frees a slot once the host's delay has passed.
+func (h *hostSlots) release() {
+	if h.delay <= 0 {
+		<-h.slots
+		return
+	}
+	time.AfterFunc(h.delay, func() { <-h.slots })
+}
+
+// releasingBody frees its request's slot when the body is closed.
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+// limitHost registers the rate limit for host.
+func (s *Scraper) limitHost(host string, delay time.Duration) {
+	s.limiter.limit(host, s.config.Processing.Concurrency, delay)
+}
+
+// waitForHost sets up the rate limit of the request's host and sleeps
+// while the host is being throttled. The sleep ends early when the crawl
+// is cancelled.
+func (s *Scraper) waitForHost(r *colly.Request) {
+	host := r.URL.Host
+	if s.config.Security.RespectRobots {
+		// Loading robots.txt registers the host's limit with its Crawl-delay
+		_, _ = s.robots.get(r.URL)
+	}
+	s.limitHost(host, s.baseDelay())
+
+	if wait := s.throttle.reserve(host); wait > 0 {
+		s.logger.WithFields(logrus.Fields{
+			"host": host,
+			"wait": wait.String(),
+		}).Debug("Waiting for throttled host")
+		s.sleep(wait)
+	}
+}
+
+// handleThrottled backs off from a host that answered 429 or 503 and
+// re-queues the request. It reports whether the response was handled,
+// which it is also once the URL is given up on, so that it is not
+// retried again by the retry policy.
+func (s *Scraper) handleThrottled(r *colly.Response, err error) bool {
+	if !isThrottled(r.StatusCode) {
+		return false
+	}
+
+	host := r.Request.URL.Host
+	urlStr := r.Request.URL.String()
+
+	var retryAfter time.Duration
+	if r.Headers != nil {
+		retryAfter = parseRetryAfter(r.Headers.Get("Retry-After"), time.Now())
+	}
+	delay := s.throttle.backoff(host, retryAfter)
+
+	attempt, ok := s.throttle.requeue(urlStr, s.config.Throttle.MaxRequeues)
+
+	s.logger.WithFields(logrus.Fields{
+		"host":        host,
+		"url":         urlStr,
+		"status":      r.StatusCode,
+		"retry_after": retryAfter.String(),
+		"delay":       delay.String(),
+		"requeue":     attempt,
+	}).Warn("🐢 Host is throttling requests, backing off")
+
+	if !ok {
+		s.logger.WithFields(logrus.Fields{
+			"url":      urlStr,
+			"requeues": attempt,
+		}).Error("Giving up on throttled URL")
+		s.recordFailure(urlStr, r.StatusCode, err, attempt+1)
+		return true
+	}
+
+	// A failing retry is reported through OnError again
+	if err := r.Request.Retry(); err != nil {
+		s.logger.WithError(err).Debugf("Re-queued request for %s failed", urlStr)
+	}
+	return true
+}
+
+func (s *Scraper) logThrottleSummary() {
+	events := s.throttle.events()
+	if len(events) == 0 {
+		return
+	}
+
+	hosts := make([]string, 0, len(events))
+	total := 0
+	for host, count := range events {
+		hosts = append(hosts, host)
+		total += count
+	}
+	sort.Strings(hosts)
+
+	s.logger.WithFields(logrus.Fields{
+		"throttle_events": total,
+		"hosts":           len(hosts),
+	}).Warn("🐢 Throttling summary")
+
+	for _, host := range hosts {
+		s.logger.WithFields(logrus.Fields{
+			"host":   host,
+			"events": events[host],
+		}).Info("Host throttled")
+	}
+}
@@ -0,0 +1,126 @@
+package scraper
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/vladkampov/markdocify/internal/config"
+)
+
+// titleReplacement replaces matches of re in a title.
+type titleReplacement struct {
+	re          *regexp.Regexp
+	replacement string
+}
+
+// titleCleaner applies the compiled title_rules to page titles.
+type titleCleaner struct {
+	replacements []titleReplacement
+	prefixes     []*regexp.Regexp
+	suffixes     []*regexp.Regexp
+	fallback     []string
+}
+
+func newTitleCleaner(cfg config.TitleRulesConfig) (*titleCleaner, error) {
+	rules := cfg.Expand()
+	c := &titleCleaner{fallback: rules.Fallback}
+
+	for _, r := range rules.Replacements {
+		re, err := regexp.Compile("(?i)" + r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid title replacement '%s': %w", r.Pattern, err)
+		}
+		c.replacements = append(c.replacements, titleReplacement{re: re, replacement: r.Replacement})
+	}
+
+	for _, pattern := range rules.StripPrefixes {
+		re, err := regexp.Compile(config.TitlePrefixPattern(pattern))
+		if err != nil {
+			return nil, fmt.Errorf("invalid title prefix '%s': %w", pattern, err)
+		}
+		c.prefixes = append(c.prefixes, re)
+	}
+
+	for _, pattern := range rules.StripSuffixes {
+		re, err := regexp.Compile(config.TitleSuffixPattern(pattern))
+		if err != nil {
+			return nil, fmt.Errorf("invalid title suffix '%s': %w", pattern, err)
+		}
+		c.suffixes = append(c.suffixes, re)
+	}
+
+	return c, nil
+}
+
+// clean applies replacements, then prefix and suffix stripping, and finally
+// collapses consecutive identical words.
+func (c *titleCleaner) clean(title string) string {
+	cleaned := strings.TrimSpace(title)
+
+	for _, r := range c.replacements {
+		cleaned = r.re.ReplaceAllString(cleaned, r.replacement)
+	}
+	for _, re := range c.prefixes {
+		cleaned = re.ReplaceAllString(cleaned, "")
+	}
+	for _, re := range c.suffixes {
+		cleaned = re.ReplaceAllString(cleaned, "")
+	}
+
+	words := strings.Fields(cleaned)
+	var deduped []string
+	for i, word := range words {
+		if i == 0 || !strings.EqualFold(word, words[i-1]) {
+			deduped = append(deduped, word)
+		}
+	}
+
+	return strings.Join(deduped, " ")
+}
+
+// extractTitle returns the first non-empty title from the configured
+// fallback sources.
+func (s *Scraper) extractTitle(e *colly.HTMLElement) string {
+	for _, source := range s.titles.fallback {
+		var title string
+		switch source {
+		case config.TitleSourceSelector:
+			if s.config.Selectors.Title != "" {
+				title = s.titles.clean(e.ChildText(s.config.Selectors.Title))
+			}
+		case config.TitleSourceTitle:
+			title = s.titles.clean(e.ChildText("title"))
+		case config.TitleSourceOGTitle:
+			title = s.titles.clean(e.ChildAttr(`meta[property="og:title"]`, "content"))
+		case config.TitleSourceURL:
+			title = titleFromSlug(e.Request.URL)
+		}
+		if title != "" {
+			return title
+		}
+	}
+
+	return "Untitled"
+}
+
+// titleFromSlug turns the last path segment of u into a title, so that
+// /docs/getting-started becomes "Getting Started".
+func titleFromSlug(u *url.URL) string {
+	slug := path.Base(strings.TrimSuffix(u.Path, "/"))
+	if slug == "." || slug == "/" {
+		return ""
+	}
+	slug = strings.TrimSuffix(slug, path.Ext(slug))
+
+	words := strings.FieldsFunc(slug, func(r rune) bool {
+		return r == '-' || r == '_'
+	})
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, " ")
+}
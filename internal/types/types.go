@@ -3,7 +3,12 @@ package types
 import "time"
 
 type PageContent struct {
-	URL       string
+	URL string
+	// BaseURL is what relative links and images in Content resolve
+	// against: the URL the page was fetched from, or its <base href>.
+	// URL is canonical and may have lost the trailing slash that matters
+	// here. Empty means URL.
+	BaseURL   string
 	Title     string
 	Content   string
 	Depth     int
@@ -0,0 +1,172 @@
+package warc
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Reader reads records from a WARC stream.
+type Reader struct {
+	r *bufio.Reader
+}
+
+// NewReader returns a Reader for r, which may be gzip-compressed.
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		// Concatenated gzip members are read as one stream
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open compressed WARC: %w", err)
+		}
+		br = bufio.NewReader(zr)
+	}
+	return &Reader{r: br}, nil
+}
+
+// Next returns the next record, or io.EOF when the stream is exhausted.
+func (r *Reader) Next() (*Record, error) {
+	var line string
+	for {
+		l, err := r.r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && strings.TrimSpace(l) == "" {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("failed to read WARC record: %w", err)
+		}
+		// Skip the blank lines that end the previous record
+		if line = strings.TrimRight(l, "\r\n"); line != "" {
+			break
+		}
+	}
+	if !strings.HasPrefix(line, "WARC/") {
+		return nil, fmt.Errorf("invalid WARC record start %q", line)
+	}
+
+	mime, err := textproto.NewReader(r.r).ReadMIMEHeader()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read WARC headers: %w", err)
+	}
+	header := http.Header(mime)
+
+	length, err := strconv.ParseInt(header.Get(HeaderContentLength), 10, 64)
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid WARC Content-Length %q", header.Get(HeaderContentLength))
+	}
+	block := make([]byte, length)
+	if _, err := io.ReadFull(r.r, block); err != nil {
+		return nil, fmt.Errorf("failed to read WARC block: %w", err)
+	}
+
+	return &Record{Header: header, Block: block}, nil
+}
+
+// Archive replays the responses of a WARC file. It is an http.RoundTripper
+// that never touches the network: a URL fetched several times is answered
+// with its recorded responses in order, the last one repeating, and URLs
+// that were never fetched get a 404.
+type Archive struct {
+	mu        sync.Mutex
+	responses map[string][][]byte
+	served    map[string]int
+	date      time.Time
+}
+
+// Open reads the response records of the WARC file at path.
+func Open(path string) (*Archive, error) {
+	file, err := os.Open(path) // #nosec G304 -- path comes from the user's config
+	if err != nil {
+		return nil, fmt.Errorf("failed to open WARC file: %w", err)
+	}
+	defer file.Close()
+
+	reader, err := NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Archive{
+		responses: make(map[string][][]byte),
+		served:    make(map[string]int),
+	}
+	for {
+		rec, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		if a.date.IsZero() {
+			a.date, _ = time.Parse(time.RFC3339, rec.Header.Get(HeaderDate))
+		}
+		if rec.Header.Get(HeaderType) != TypeResponse {
+			continue
+		}
+		target := rec.Header.Get(HeaderTargetURI)
+		a.responses[target] = append(a.responses[target], rec.Block)
+	}
+
+	return a, nil
+}
+
+// Date returns the date of the archive's first record.
+func (a *Archive) Date() time.Time {
+	return a.date
+}
+
+// Len returns the number of URLs with recorded responses.
+func (a *Archive) Len() int {
+	return len(a.responses)
+}
+
+// RoundTrip answers req from the archive.
+func (a *Archive) RoundTrip(req *http.Request) (*http.Response, error) {
+	target := req.URL.String()
+
+	a.mu.Lock()
+	recorded := a.responses[target]
+	var block []byte
+	if len(recorded) > 0 {
+		i := a.served[target]
+		if i >= len(recorded) {
+			i = len(recorded) - 1
+		}
+		block = recorded[i]
+		a.served[target] = i + 1
+	}
+	a.mu.Unlock()
+
+	if block == nil {
+		return &http.Response{
+			Status:     "404 Not Found",
+			StatusCode: http.StatusNotFound,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     make(http.Header),
+			Body:       http.NoBody,
+			Request:    req,
+		}, nil
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(block)), req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse archived response for %s: %w", target, err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,299 @@
+// Package warc records HTTP exchanges to WARC files and replays them.
+package warc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha1" // #nosec G505 -- WARC digests are SHA-1 by convention
+	"encoding/base32"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Version is the WARC format version written by Writer.
+const Version = "WARC/1.1"
+
+// Record types used by markdocify.
+const (
+	TypeWarcinfo = "warcinfo"
+	TypeRequest  = "request"
+	TypeResponse = "response"
+)
+
+// Header names of WARC records.
+const (
+	HeaderType          = "WARC-Type"
+	HeaderRecordID      = "WARC-Record-ID"
+	HeaderDate          = "WARC-Date"
+	HeaderTargetURI     = "WARC-Target-URI"
+	HeaderConcurrentTo  = "WARC-Concurrent-To"
+	HeaderBlockDigest   = "WARC-Block-Digest"
+	HeaderPayloadDigest = "WARC-Payload-Digest"
+	HeaderTruncated     = "WARC-Truncated"
+	HeaderContentType   = "Content-Type"
+	HeaderContentLength = "Content-Length"
+)
+
+// fieldOrder is the order known fields are written in.
+var fieldOrder = []string{
+	HeaderType,
+	HeaderRecordID,
+	HeaderDate,
+	HeaderTargetURI,
+	HeaderConcurrentTo,
+	HeaderContentType,
+	HeaderBlockDigest,
+	HeaderPayloadDigest,
+	HeaderTruncated,
+	HeaderContentLength,
+}
+
+// RedactedHeaders are left out of recorded exchanges, so that credentials
+// and session cookies never end up in an archive.
+var RedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// Record is a single WARC record.
+type Record struct {
+	Header http.Header
+	Block  []byte
+}
+
+// Writer appends records to a WARC file. Files ending in .gz are written
+// with one gzip member per record, as is usual for .warc.gz. It is safe
+// for concurrent use.
+type Writer struct {
+	mu   sync.Mutex
+	file *os.File
+	gzip bool
+	date time.Time
+}
+
+// Create creates the WARC file at path and writes its warcinfo record.
+func Create(path, software string) (*Writer, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return nil, fmt.Errorf("failed to create WARC directory: %w", err)
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create WARC file: %w", err)
+	}
+	w := &Writer{
+		file: file,
+		gzip: strings.HasSuffix(path, ".gz"),
+		date: time.Now().UTC().Truncate(time.Second),
+	}
+
+	info := Record{
+		Header: http.Header{},
+		Block:  []byte(fmt.Sprintf("software: %s\r\nformat: WARC File Format 1.1\r\n", software)),
+	}
+	info.Header.Set(HeaderType, TypeWarcinfo)
+	info.Header.Set(HeaderDate, w.date.Format(time.RFC3339))
+	info.Header.Set(HeaderContentType, "application/warc-fields")
+	if err := w.Write(info); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write appends rec, filling in the record ID, date, length and digest
+// headers when they are missing.
+func (w *Writer) Write(rec Record) error {
+	header := rec.Header.Clone()
+	if header.Get(HeaderRecordID) == "" {
+		header.Set(HeaderRecordID, NewRecordID())
+	}
+	if header.Get(HeaderDate) == "" {
+		header.Set(HeaderDate, time.Now().UTC().Format(time.RFC3339))
+	}
+	header.Set(HeaderBlockDigest, digest(rec.Block))
+	header.Set(HeaderContentLength, strconv.Itoa(len(rec.Block)))
+
+	var buf bytes.Buffer
+	buf.WriteString(Version + "\r\n")
+	// Known fields keep their conventional spelling, which http.Header
+	// would otherwise canonicalize to "Warc-Date" and the like
+	for _, name := range fieldOrder {
+		for _, value := range header.Values(name) {
+			fmt.Fprintf(&buf, "%s: %s\r\n", name, value)
+		}
+		header.Del(name)
+	}
+	if err := header.WriteSubset(&buf, nil); err != nil {
+		return fmt.Errorf("failed to encode WARC record: %w", err)
+	}
+	buf.WriteString("\r\n")
+	buf.Write(rec.Block)
+	buf.WriteString("\r\n\r\n")
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var out io.Writer = w.file
+	var zw *gzip.Writer
+	if w.gzip {
+		zw = gzip.NewWriter(w.file)
+		out = zw
+	}
+	if _, err := out.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write WARC record: %w", err)
+	}
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return fmt.Errorf("failed to write WARC record: %w", err)
+		}
+	}
+	return nil
+}
+
+// Date returns the date of the warcinfo record, which Archive.Date reports
+// when the file is replayed.
+func (w *Writer) Date() time.Time {
+	return w.date
+}
+
+// Close closes the underlying file.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
+
+// Recorder is an http.RoundTripper that writes every exchange made through
+// Transport to Writer as a request and a response record. Headers listed
+// in RedactedHeaders are not recorded.
+type Recorder struct {
+	Transport http.RoundTripper
+	Writer    *Writer
+	// MaxBodySize caps how much of a response body is read for its
+	// record. Longer bodies are recorded with their first MaxBodySize+1
+	// bytes and marked truncated, so that a replay still sees them as too
+	// large. Zero means no limit.
+	MaxBodySize int64
+}
+
+// RoundTrip performs the request and records it. The caller gets the
+// response body unchanged, with whatever part of it lies past MaxBodySize
+// still unread.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := io.Reader(resp.Body)
+	if r.MaxBodySize > 0 {
+		reader = io.LimitReader(resp.Body, r.MaxBodySize+1)
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	truncated := r.MaxBodySize > 0 && int64(len(body)) > r.MaxBodySize
+	resp.Body = &replayedBody{Reader: io.MultiReader(bytes.NewReader(body), resp.Body), Closer: resp.Body}
+
+	if err := r.record(req, resp, body, truncated); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// replayedBody hands back the part of a body read for its record followed
+// by the rest of it.
+type replayedBody struct {
+	io.Reader
+	io.Closer
+}
+
+func (r *Recorder) record(req *http.Request, resp *http.Response, body []byte, truncated bool) error {
+	date := time.Now().UTC().Format(time.RFC3339)
+	responseID := NewRecordID()
+
+	// Record with an explicit length so the block parses back unchunked
+	recorded := *resp
+	recorded.Header = redact(resp.Header)
+	recorded.Body = io.NopCloser(bytes.NewReader(body))
+	recorded.ContentLength = int64(len(body))
+	recorded.TransferEncoding = nil
+	// A body the transport decompressed is recorded as it was decoded,
+	// so it must not claim the encoding or length it was sent with
+	if resp.Uncompressed {
+		recorded.Header.Del("Content-Encoding")
+		recorded.Header.Del("Content-Length")
+	}
+
+	var response bytes.Buffer
+	if err := recorded.Write(&response); err != nil {
+		return fmt.Errorf("failed to encode response for WARC: %w", err)
+	}
+	responseRecord := Record{Header: http.Header{}, Block: response.Bytes()}
+	responseRecord.Header.Set(HeaderType, TypeResponse)
+	responseRecord.Header.Set(HeaderRecordID, responseID)
+	responseRecord.Header.Set(HeaderDate, date)
+	responseRecord.Header.Set(HeaderTargetURI, req.URL.String())
+	responseRecord.Header.Set(HeaderContentType, "application/http; msgtype=response")
+	responseRecord.Header.Set(HeaderPayloadDigest, digest(body))
+	if truncated {
+		responseRecord.Header.Set(HeaderTruncated, "length")
+	}
+
+	outgoing := req.Clone(req.Context())
+	outgoing.Header = redact(req.Header)
+	request, err := httputil.DumpRequestOut(outgoing, false)
+	if err != nil {
+		return fmt.Errorf("failed to encode request for WARC: %w", err)
+	}
+	requestRecord := Record{Header: http.Header{}, Block: request}
+	requestRecord.Header.Set(HeaderType, TypeRequest)
+	requestRecord.Header.Set(HeaderDate, date)
+	requestRecord.Header.Set(HeaderTargetURI, req.URL.String())
+	requestRecord.Header.Set(HeaderConcurrentTo, responseID)
+	requestRecord.Header.Set(HeaderContentType, "application/http; msgtype=request")
+
+	if err := r.Writer.Write(requestRecord); err != nil {
+		return err
+	}
+	return r.Writer.Write(responseRecord)
+}
+
+func redact(header http.Header) http.Header {
+	header = header.Clone()
+	for _, name := range RedactedHeaders {
+		header.Del(name)
+	}
+	return header
+}
+
+// NewRecordID returns a random urn:uuid record ID.
+func NewRecordID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("<urn:uuid:%x-%x-%x-%x-%x>", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+func digest(data []byte) string {
+	sum := sha1.Sum(data) // #nosec G401
+	return "sha1:" + base32.StdEncoding.EncodeToString(sum[:])
+}
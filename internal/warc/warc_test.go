@@ -0,0 +1,192 @@
+package warc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	for _, name := range []string{"crawl.warc", "crawl.warc.gz"} {
+		t.Run(name, func(t *testing.T) {
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if r.URL.Path == "/flaky" && calls == 1 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.Header().Set("Content-Type", "text/html")
+				fmt.Fprintf(w, "<p>%s</p>\r\n", r.URL.Path)
+			}))
+			defer server.Close()
+
+			path := filepath.Join(t.TempDir(), name)
+			writer, err := Create(path, "test")
+			require.NoError(t, err)
+			client := &http.Client{Transport: &Recorder{Writer: writer}}
+
+			var bodies []string
+			for _, p := range []string{"/page", "/flaky", "/flaky"} {
+				resp, err := client.Get(server.URL + p)
+				require.NoError(t, err)
+				body, err := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				require.NoError(t, err)
+				bodies = append(bodies, fmt.Sprintf("%d %s", resp.StatusCode, body))
+			}
+			require.NoError(t, writer.Close())
+
+			archive, err := Open(path)
+			require.NoError(t, err)
+			assert.Equal(t, 2, archive.Len())
+			assert.Equal(t, writer.Date(), archive.Date())
+
+			replay := &http.Client{Transport: archive}
+			var replayed []string
+			for _, p := range []string{"/page", "/flaky", "/flaky", "/flaky"} {
+				resp, err := replay.Get(server.URL + p)
+				require.NoError(t, err)
+				body, err := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				require.NoError(t, err)
+				replayed = append(replayed, fmt.Sprintf("%d %s", resp.StatusCode, body))
+				if resp.StatusCode == http.StatusOK {
+					assert.Equal(t, "text/html", resp.Header.Get("Content-Type"), p)
+				}
+			}
+			assert.Equal(t, bodies, replayed[:3])
+			assert.Equal(t, replayed[2], replayed[3], "the last response repeats")
+			assert.Equal(t, 3, calls, "replay does not touch the network")
+
+			resp, err := replay.Get(server.URL + "/never")
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		})
+	}
+}
+
+func TestReaderRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "records.warc")
+	writer, err := Create(path, "test")
+	require.NoError(t, err)
+
+	rec := Record{Header: http.Header{}, Block: []byte("hello\r\n\r\nworld")}
+	rec.Header.Set(HeaderType, "resource")
+	rec.Header.Set(HeaderTargetURI, "https://example.com/hello")
+	require.NoError(t, writer.Write(rec))
+	require.NoError(t, writer.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(data, []byte("WARC/1.1\r\nWARC-Type: warcinfo\r\n")))
+
+	reader, err := NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	info, err := reader.Next()
+	require.NoError(t, err)
+	assert.Equal(t, TypeWarcinfo, info.Header.Get(HeaderType))
+	assert.Contains(t, string(info.Block), "software: test")
+
+	got, err := reader.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "resource", got.Header.Get(HeaderType))
+	assert.Equal(t, "https://example.com/hello", got.Header.Get(HeaderTargetURI))
+	assert.Equal(t, rec.Block, got.Block)
+	assert.Equal(t, digest(rec.Block), got.Header.Get(HeaderBlockDigest))
+	assert.NotEmpty(t, got.Header.Get(HeaderRecordID))
+
+	_, err = reader.Next()
+	assert.ErrorIs(t, err, io.EOF)
+
+	bad, err := NewReader(bytes.NewReader([]byte("HTTP/1.1 200 OK\r\n\r\n")))
+	require.NoError(t, err)
+	_, err = bad.Next()
+	assert.Error(t, err)
+}
+
+func TestRecorderLimitsAndDecodedBodies(t *testing.T) {
+	large := strings.Repeat("x", 100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gzip" {
+			w.Header().Set("Content-Encoding", "gzip")
+			zw := gzip.NewWriter(w)
+			_, _ = zw.Write([]byte("<p>compressed</p>"))
+			_ = zw.Close()
+			return
+		}
+		_, _ = w.Write([]byte(large))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "limits.warc")
+	writer, err := Create(path, "test")
+	require.NoError(t, err)
+	client := &http.Client{Transport: &Recorder{Writer: writer, MaxBodySize: 50}}
+
+	for _, p := range []string{"/large", "/gzip"} {
+		resp, err := client.Get(server.URL + p)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+		if p == "/large" {
+			assert.Equal(t, large, string(body), "the caller still gets the whole body")
+		} else {
+			assert.Equal(t, "<p>compressed</p>", string(body))
+		}
+	}
+	require.NoError(t, writer.Close())
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	reader, err := NewReader(file)
+	require.NoError(t, err)
+	truncated := map[string]string{}
+	for {
+		rec, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		if rec.Header.Get(HeaderType) == TypeResponse {
+			truncated[rec.Header.Get(HeaderTargetURI)] = rec.Header.Get(HeaderTruncated)
+		}
+	}
+	assert.Equal(t, "length", truncated[server.URL+"/large"])
+	assert.Empty(t, truncated[server.URL+"/gzip"])
+
+	archive, err := Open(path)
+	require.NoError(t, err)
+	replay := &http.Client{Transport: archive}
+
+	resp, err := replay.Get(server.URL + "/large")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Len(t, body, 51, "a truncated record keeps one byte past the limit")
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/gzip", nil)
+	require.NoError(t, err)
+	resp, err = archive.RoundTrip(req)
+	require.NoError(t, err)
+	body, err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Empty(t, resp.Header.Get("Content-Encoding"), "decoded bodies are not recorded as encoded")
+	assert.Equal(t, "<p>compressed</p>", string(body))
+}